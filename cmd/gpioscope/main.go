@@ -0,0 +1,128 @@
+// +build linux
+
+// Command gpioscope samples a GPIO at a fixed rate for a while and then
+// draws the resulting waveform on the terminal, or writes it out as CSV for
+// further analysis in a spreadsheet.
+//
+// Usage:
+//
+//	gpioscope [flags] <gpio-number>
+//
+// The GPIO must already be exported. Sampling is done by reading the sysfs
+// "value" file, so rates above a few kilohertz are unlikely to be achieved
+// and samples may be delayed by scheduling jitter.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"github.com/apparentlymart/go-linuxgpio/linuxgpio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	rate := flag.Float64("rate", 1000, "samples per second")
+	duration := flag.Duration("duration", time.Second, "how long to sample for")
+	width := flag.Int("width", 80, "samples per line of the waveform")
+	csvOutput := flag.Bool("csv", false, "write samples as CSV instead of drawing a waveform")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <gpio-number>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *rate <= 0 || *width <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	number, err := strconv.Atoi(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid GPIO number %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	pin, err := linuxgpio.MakeNode(number).Open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open GPIO %d: %s\n", number, err)
+		os.Exit(1)
+	}
+	defer pin.Close()
+
+	interval := time.Duration(float64(time.Second) / *rate)
+	count := int(duration.Seconds() * *rate)
+	samples, err := sample(pin, interval, count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read GPIO %d: %s\n", number, err)
+		os.Exit(1)
+	}
+
+	if *csvOutput {
+		err = writeCSV(samples, interval)
+	} else {
+		drawWaveform(samples, interval, *width)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write output: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// sample reads the given number of values from the pin at the given
+// interval. If a read takes longer than the interval, subsequent reads
+// happen as soon as possible until sampling catches up.
+func sample(pin linuxgpio.Pin, interval time.Duration, count int) ([]gpio.Value, error) {
+	samples := make([]gpio.Value, count)
+	next := time.Now()
+	for i := range samples {
+		value, err := pin.Value()
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = value
+
+		next = next.Add(interval)
+		time.Sleep(time.Until(next))
+	}
+	return samples, nil
+}
+
+func drawWaveform(samples []gpio.Value, interval time.Duration, width int) {
+	for start := 0; start < len(samples); start += width {
+		end := start + width
+		if end > len(samples) {
+			end = len(samples)
+		}
+
+		var line strings.Builder
+		for _, value := range samples[start:end] {
+			if value == gpio.High {
+				line.WriteString("█")
+			} else {
+				line.WriteString("░")
+			}
+		}
+
+		offset := time.Duration(start) * interval
+		fmt.Printf("%12s %s\n", offset, line.String())
+	}
+}
+
+func writeCSV(samples []gpio.Value, interval time.Duration) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"seconds", "value"})
+	for i, value := range samples {
+		offset := time.Duration(i) * interval
+		bit := "0"
+		if value == gpio.High {
+			bit = "1"
+		}
+		w.Write([]string{strconv.FormatFloat(offset.Seconds(), 'f', -1, 64), bit})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,159 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aggregatorDriverPath is the sysfs directory of the gpio-aggregator
+// driver, which exists only if the kernel was built with it.
+const aggregatorDriverPath = "/sys/bus/platform/drivers/gpio-aggregator"
+
+// aggregatorLabelPrefix begins the label of each chip created by the
+// gpio-aggregator driver, which labels its chips with their device names.
+const aggregatorLabelPrefix = "gpio-aggregator."
+
+// aggregatorTimeout is how long NewGpioAggregator waits for the new chip to
+// appear in sysfs.
+const aggregatorTimeout = time.Second
+
+// GpioLine identifies a GPIO by the chip that provides it and its offset
+// within that chip, for use with NewGpioAggregator.
+type GpioLine struct {
+	// ChipLabel is the label of the chip, as returned by GpioChip.Label,
+	// or its sysfs name such as "gpiochip0".
+	ChipLabel string
+
+	// Offset is the GPIO's offset from the chip's first GPIO.
+	Offset int
+}
+
+// NewGpioAggregator uses the kernel's gpio-aggregator driver, available
+// since Linux 5.6, to create a new chip that provides the given GPIOs, in
+// the given order, which may belong to several different chips. Returns the
+// new chip once it has appeared in sysfs.
+//
+// The kernel chooses the name of the new chip, which is also its label and
+// so can be found using GpioChip.Label. Pass that name to DeleteAggregator
+// to remove the chip again.
+//
+// The new chip is recognized as the one that wasn't present before, so
+// programs must not create aggregated chips concurrently. Fails with an
+// error wrapping ErrUnsupported if the kernel has no gpio-aggregator driver.
+func NewGpioAggregator(lines []GpioLine) (GpioChip, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("no GPIOs to aggregate")
+	}
+
+	// The driver accepts a list of chips, each followed by a comma-separated
+	// list of offsets, such as "gpiochip0 1,2 gpiochip1 3".
+	var spec strings.Builder
+	for i, line := range lines {
+		if line.ChipLabel == "" || strings.ContainsAny(line.ChipLabel, " \t\n") {
+			return nil, fmt.Errorf("chip label %q cannot be used with gpio-aggregator", line.ChipLabel)
+		}
+		if line.Offset < 0 {
+			return nil, fmt.Errorf("%w: negative offset %d", ErrInvalidPinNumber, line.Offset)
+		}
+
+		if i > 0 && line.ChipLabel == lines[i-1].ChipLabel {
+			spec.WriteByte(',')
+		} else {
+			if i > 0 {
+				spec.WriteByte(' ')
+			}
+			spec.WriteString(line.ChipLabel)
+			spec.WriteByte(' ')
+		}
+		spec.WriteString(strconv.Itoa(line.Offset))
+	}
+
+	existing, err := aggregatorChips()
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeAggregatorFile("new_device", spec.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// The kernel creates the chip asynchronously, so we must poll for it as
+	// in WaitForExport.
+	deadline := time.Now().Add(aggregatorTimeout)
+	delay := time.Millisecond
+	for {
+		chips, err := aggregatorChips()
+		if err != nil {
+			return nil, err
+		}
+		for label, chip := range chips {
+			if _, ok := existing[label]; !ok {
+				return chip, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: aggregated chip did not appear", ErrChipNotFound)
+		}
+		time.Sleep(delay)
+		delay *= 2
+		if delay > 100*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+	}
+}
+
+// DeleteAggregator removes a chip previously created by NewGpioAggregator,
+// given its name, such as "gpio-aggregator.0". Any of its GPIOs that are
+// exported are unexported by the kernel.
+func DeleteAggregator(name string) error {
+	if !strings.HasPrefix(name, aggregatorLabelPrefix) {
+		return fmt.Errorf("%w: %q is not an aggregated chip", ErrChipNotFound, name)
+	}
+	return writeAggregatorFile("delete_device", name)
+}
+
+// aggregatorChips returns the chips created by the gpio-aggregator driver,
+// keyed by label.
+func aggregatorChips() (map[string]GpioChip, error) {
+	chips, err := ListGpioChips()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]GpioChip)
+	for _, chip := range chips {
+		label, err := chip.Label()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(label, aggregatorLabelPrefix) {
+			result[label] = chip
+		}
+	}
+	return result, nil
+}
+
+// writeAggregatorFile writes the given value to one of the gpio-aggregator
+// driver's control files.
+func writeAggregatorFile(name string, value string) error {
+	file, err := os.OpenFile(filepath.Join(aggregatorDriverPath, name), os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: kernel has no gpio-aggregator driver", ErrUnsupported)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(value)
+	return err
+}
@@ -0,0 +1,82 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// AutoClosePin wraps a Pin so that it is closed automatically if the
+// process receives SIGTERM, as happens when a service is stopped by systemd.
+type AutoClosePin struct {
+	Pin
+	signals chan os.Signal
+	stop    chan struct{}
+	once    sync.Once
+
+	// closeOnce ensures that the pin is closed only once, whether by the
+	// signal handler or by Close.
+	closeOnce sync.Once
+}
+
+// AutoClose installs a SIGTERM handler that closes the given pin.
+//
+// Once the pin has been closed the handler is removed and SIGTERM is raised
+// again, so that if nothing else in the program handles SIGTERM, the process
+// still terminates as it would have without the handler.
+//
+// If some other part of the program has also registered for SIGTERM using
+// signal.Notify, it sees the signal twice, and the process keeps running
+// unless that handler causes it to exit. The pin will then already have been
+// closed by the time that handler runs, so any further use of it fails,
+// though calling Close on the returned AutoClosePin is harmless.
+func AutoClose(pin Pin) *AutoClosePin {
+	p := &AutoClosePin{
+		Pin:     pin,
+		signals: make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+	}
+	signal.Notify(p.signals, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-p.signals:
+			p.closePin()
+			p.Deregister()
+			syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		case <-p.stop:
+		}
+	}()
+
+	return p
+}
+
+// Deregister removes the SIGTERM handler without closing the pin. It is safe
+// to call more than once.
+func (p *AutoClosePin) Deregister() error {
+	p.once.Do(func() {
+		signal.Stop(p.signals)
+		close(p.stop)
+	})
+	return nil
+}
+
+// Close removes the SIGTERM handler and then closes the pin. If the pin was
+// already closed because SIGTERM was received, or by an earlier call to
+// Close, this does nothing and returns nil.
+func (p *AutoClosePin) Close() error {
+	p.Deregister()
+	return p.closePin()
+}
+
+// closePin closes the underlying pin, unless it has already been closed in
+// which case it returns nil.
+func (p *AutoClosePin) closePin() (err error) {
+	p.closeOnce.Do(func() {
+		err = p.Pin.Close()
+	})
+	return err
+}
@@ -0,0 +1,250 @@
+// +build linux
+
+// Package bcm2835 provides faster GPIO access on the Broadcom BCM2835 family
+// of SoCs, as used on the Raspberry Pi, by manipulating the GPIO controller's
+// registers directly through the memory mapping exposed at /dev/gpiomem.
+//
+// Only reading and writing values is done via the registers. Everything else,
+// including direction and edge configuration and waiting for edges, is
+// delegated to the portable sysfs implementation in the parent package, so
+// the kernel's view of each GPIO remains consistent. A GPIO must therefore
+// be exported in sysfs before it can be opened with this package.
+package bcm2835
+
+import (
+	"errors"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"github.com/apparentlymart/go-linuxgpio/linuxgpio"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Word offsets of the registers we use within the GPIO register block. Each
+// is the first of a pair, with the second register (e.g. GPSET1) immediately
+// following and covering GPIOs 32 and up.
+const (
+	regGPSET0 = 0x1c / 4
+	regGPCLR0 = 0x28 / 4
+	regGPLEV0 = 0x34 / 4
+	regGPEDS0 = 0x40 / 4
+
+	blockSize = 4096
+	gpioCount = 54
+)
+
+// headerPins maps the physical pin numbers of the 40-pin Raspberry Pi
+// header to the BCM2835 GPIO numbers they are connected to. Pins not listed
+// are power, ground or reserved.
+var headerPins = map[int]int{
+	3: 2, 5: 3, 7: 4, 8: 14, 10: 15, 11: 17, 12: 18, 13: 27, 15: 22,
+	16: 23, 18: 24, 19: 10, 21: 9, 22: 25, 23: 11, 24: 8, 26: 7, 27: 0,
+	28: 1, 29: 5, 31: 6, 32: 12, 33: 13, 35: 19, 36: 16, 37: 26, 38: 20,
+	40: 21,
+}
+
+// Registers provides access to the memory-mapped GPIO registers of the
+// BCM2835. All operations take a BCM GPIO number between 0 and 53, and
+// select the first or second register of each pair as appropriate.
+type Registers struct {
+	words []uint32
+}
+
+var (
+	registersOnce sync.Once
+	registers     *Registers
+	registersErr  error
+)
+
+// OpenRegisters maps the GPIO registers from /dev/gpiomem, which is readable
+// and writable by members of the "gpio" group on Raspberry Pi OS and does
+// not require root privileges.
+//
+// The mapping is created only once and is shared by all callers for the
+// remaining lifetime of the process.
+func OpenRegisters() (*Registers, error) {
+	registersOnce.Do(func() {
+		var file *os.File
+		file, registersErr = os.OpenFile("/dev/gpiomem", os.O_RDWR|os.O_SYNC, 0)
+		if registersErr != nil {
+			return
+		}
+		defer file.Close()
+
+		var mem []byte
+		mem, registersErr = syscall.Mmap(
+			int(file.Fd()), 0, blockSize,
+			syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED,
+		)
+		if registersErr != nil {
+			return
+		}
+
+		words := unsafe.Slice((*uint32)(unsafe.Pointer(&mem[0])), blockSize/4)
+		registers = &Registers{words: words}
+	})
+	return registers, registersErr
+}
+
+// bank returns the register to use for the given GPIO out of a pair whose
+// first register is at the given offset, along with the GPIO's bit mask
+// within that register.
+func (regs *Registers) bank(first int, number int) (*uint32, uint32) {
+	if number < 0 || number >= gpioCount {
+		// should never happen in a valid program
+		panic("Invalid BCM2835 GPIO number")
+	}
+	return &regs.words[first+number/32], 1 << uint(number%32)
+}
+
+// Set drives the given GPIO high by writing its bit in GPSET0 or GPSET1.
+// It has no effect if the GPIO is not configured as an output.
+func (regs *Registers) Set(number int) {
+	reg, mask := regs.bank(regGPSET0, number)
+	atomic.StoreUint32(reg, mask)
+}
+
+// Clear drives the given GPIO low by writing its bit in GPCLR0 or GPCLR1.
+// It has no effect if the GPIO is not configured as an output.
+func (regs *Registers) Clear(number int) {
+	reg, mask := regs.bank(regGPCLR0, number)
+	atomic.StoreUint32(reg, mask)
+}
+
+// Level returns the current level of the given GPIO, as reported by GPLEV0
+// or GPLEV1.
+func (regs *Registers) Level(number int) bool {
+	reg, mask := regs.bank(regGPLEV0, number)
+	return atomic.LoadUint32(reg)&mask != 0
+}
+
+// EventDetected returns true if the given GPIO's bit is set in GPEDS0 or
+// GPEDS1, indicating that an event matching its configured edge or level
+// detection has occurred since the bit was last cleared.
+//
+// When edge detection is configured via sysfs, the kernel's interrupt
+// handler clears these bits itself, so this is mainly useful with detection
+// configured by other means.
+func (regs *Registers) EventDetected(number int) bool {
+	reg, mask := regs.bank(regGPEDS0, number)
+	return atomic.LoadUint32(reg)&mask != 0
+}
+
+// ClearEvent clears the given GPIO's bit in GPEDS0 or GPEDS1.
+func (regs *Registers) ClearEvent(number int) {
+	reg, mask := regs.bank(regGPEDS0, number)
+	atomic.StoreUint32(reg, mask)
+}
+
+// GpioNumber returns the BCM2835 GPIO number connected to the given physical
+// pin of the Raspberry Pi's 40-pin header, or an error if that physical pin
+// is not a GPIO.
+func GpioNumber(physicalPin int) (int, error) {
+	number, ok := headerPins[physicalPin]
+	if !ok {
+		return 0, fmt.Errorf("header pin %d is not a GPIO", physicalPin)
+	}
+	return number, nil
+}
+
+// chipCompatibles are the device tree compatible strings of the GPIO
+// controllers whose registers this package understands.
+var chipCompatibles = []string{"brcm,bcm2835-gpio", "brcm,bcm2711-gpio"}
+
+// SysfsNumber returns the sysfs GPIO number of the given BCM2835 GPIO
+// number, which is the BCM number offset by the first GPIO number of the
+// controller. Older kernels number the controller from zero, so the two are
+// the same, but since Linux 6.6 the kernel chooses the first number itself
+// and on the Raspberry Pi it is typically 512.
+func SysfsNumber(bcmNumber int) (int, error) {
+	if bcmNumber < 0 || bcmNumber >= gpioCount {
+		return 0, fmt.Errorf("%w: no BCM2835 GPIO %d", linuxgpio.ErrInvalidPinNumber, bcmNumber)
+	}
+
+	var chip linuxgpio.GpioChip
+	var err error
+	for _, compatible := range chipCompatibles {
+		chip, err = linuxgpio.FindChipByCompatible(compatible)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, linuxgpio.ErrChipNotFound) {
+			return 0, err
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	first, err := chip.FirstGpioNumber()
+	if err != nil {
+		return 0, err
+	}
+	return first + bcmNumber, nil
+}
+
+type bcm2835Pin struct {
+	linuxgpio.Pin
+	regs *Registers
+
+	// bcmNumber is the GPIO's number within the controller, used to find
+	// its bits in the registers, which differs from its sysfs number on
+	// newer kernels.
+	bcmNumber int
+}
+
+// NewBcm2835Pin opens the GPIO connected to the given physical pin of the
+// Raspberry Pi's 40-pin header. The GPIO must already be exported, using the
+// number returned by SysfsNumber for the BCM number returned by GpioNumber.
+//
+// The returned pin reads and writes values using the memory-mapped registers
+// when /dev/gpiomem is available, and otherwise behaves exactly like a pin
+// opened via sysfs. Because the registers reflect the electrical state of
+// the pin, the sysfs active_low setting has no effect on values read or
+// written through the registers.
+func NewBcm2835Pin(physicalPin int) (linuxgpio.Pin, error) {
+	bcmNumber, err := GpioNumber(physicalPin)
+	if err != nil {
+		return nil, err
+	}
+	number, err := SysfsNumber(bcmNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	pin, err := linuxgpio.MakeNode(number).Open()
+	if err != nil {
+		return nil, err
+	}
+
+	regs, err := OpenRegisters()
+	if err != nil {
+		// Fall back on plain sysfs access.
+		return pin, nil
+	}
+
+	return &bcm2835Pin{Pin: pin, regs: regs, bcmNumber: bcmNumber}, nil
+}
+
+func (pin *bcm2835Pin) SetValue(value gpio.Value) error {
+	switch value {
+	case gpio.High:
+		pin.regs.Set(pin.bcmNumber)
+	case gpio.Low:
+		pin.regs.Clear(pin.bcmNumber)
+	default:
+		// should never happen in a valid program
+		panic("Invalid gpio.Value value")
+	}
+	return nil
+}
+
+func (pin *bcm2835Pin) Value() (gpio.Value, error) {
+	if pin.regs.Level(pin.bcmNumber) {
+		return gpio.High, nil
+	}
+	return gpio.Low, nil
+}
@@ -0,0 +1,74 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"syscall"
+)
+
+// cancelPipe allows a blocking EpollWait call to be interrupted when a
+// context is cancelled.
+//
+// It is a pipe whose read end is added to an epoll set, and whose write end
+// is closed when the context is cancelled, which makes the read end readable
+// and thus wakes up any EpollWait call on that set.
+type cancelPipe struct {
+	readFd  int
+	epollFd int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// newCancelPipe creates a cancelPipe for the given context and adds its read
+// end to the given epoll set. The caller must call close once it is no
+// longer waiting.
+func newCancelPipe(ctx context.Context, epollFd int) (*cancelPipe, error) {
+	var fds [2]int
+	err := syscall.Pipe2(fds[:], syscall.O_CLOEXEC|syscall.O_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	readFd, writeFd := fds[0], fds[1]
+
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(readFd)}
+	err = syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, readFd, &event)
+	if err != nil {
+		syscall.Close(readFd)
+		syscall.Close(writeFd)
+		return nil, err
+	}
+
+	p := &cancelPipe{
+		readFd:  readFd,
+		epollFd: epollFd,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	// The goroutine takes ownership of the write end of the pipe and closes
+	// it either when the context is cancelled or when we're closed.
+	go func() {
+		defer close(p.stopped)
+		select {
+		case <-ctx.Done():
+		case <-p.stop:
+		}
+		syscall.Close(writeFd)
+	}()
+
+	return p, nil
+}
+
+// fd returns the file descriptor of the read end of the pipe, as it will
+// appear in events returned from EpollWait.
+func (p *cancelPipe) fd() int32 {
+	return int32(p.readFd)
+}
+
+func (p *cancelPipe) close() {
+	syscall.EpollCtl(p.epollFd, syscall.EPOLL_CTL_DEL, p.readFd, nil)
+	close(p.stop)
+	<-p.stopped
+	syscall.Close(p.readFd)
+}
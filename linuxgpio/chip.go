@@ -0,0 +1,179 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrChipNotFound is returned when searching for a GPIO chip that doesn't
+// exist.
+var ErrChipNotFound = errors.New("GPIO chip not found")
+
+type gpioChip struct {
+	path string
+}
+
+// MakeGpioChip returns a GpioChip for the chip whose sysfs directory is at
+// the given path, such as "/sys/class/gpio/gpiochip0".
+func MakeGpioChip(path string) (GpioChip, error) {
+	_, err := os.Stat(filepath.Join(path, "base"))
+	if err != nil {
+		return nil, err
+	}
+	return &gpioChip{path: path}, nil
+}
+
+// ListGpioChips returns all of the GPIO chips currently registered with the
+// kernel's sysfs GPIO interface.
+func ListGpioChips() ([]GpioChip, error) {
+	paths, err := filepath.Glob("/sys/class/gpio/gpiochip*")
+	if err != nil {
+		return nil, err
+	}
+
+	chips := make([]GpioChip, len(paths))
+	for i, path := range paths {
+		chips[i] = &gpioChip{path: path}
+	}
+	return chips, nil
+}
+
+// FindChipByLabel returns the first GPIO chip whose label, as assigned by
+// its kernel driver, is the given string, such as "raspberrypi-gpio". This
+// allows a chip to be found even if its GPIO numbers vary between kernels.
+func FindChipByLabel(label string) (GpioChip, error) {
+	chips, err := ListGpioChips()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chip := range chips {
+		candidate, err := chip.Label()
+		if err != nil {
+			return nil, err
+		}
+		if candidate == label {
+			return chip, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no chip is labelled %q", ErrChipNotFound, label)
+}
+
+// FindChipByCompatible returns the first GPIO chip whose device tree node
+// lists the given string, such as "brcm,bcm2711-gpio", among its compatible
+// strings. This is usually more stable across kernel versions than a chip's
+// label.
+//
+// Chips that were not described by a device tree are never matched.
+func FindChipByCompatible(compatible string) (GpioChip, error) {
+	chips, err := ListGpioChips()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chip := range chips {
+		path := filepath.Join(chip.(*gpioChip).path, "device", "of_node", "compatible")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// The attribute contains a list of NUL-terminated strings.
+		for _, candidate := range strings.Split(string(data), "\x00") {
+			if candidate == compatible {
+				return chip, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no chip is compatible with %q", ErrChipNotFound, compatible)
+}
+
+func (chip *gpioChip) FirstGpioNumber() (int, error) {
+	return readSysfsInt(filepath.Join(chip.path, "base"))
+}
+
+func (chip *gpioChip) GpioCount() (int, error) {
+	return readSysfsInt(filepath.Join(chip.path, "ngpio"))
+}
+
+func (chip *gpioChip) LastGpioNumber() (int, error) {
+	first, err := chip.FirstGpioNumber()
+	if err != nil {
+		return 0, err
+	}
+	count, err := chip.GpioCount()
+	if err != nil {
+		return 0, err
+	}
+	return first + count - 1, nil
+}
+
+func (chip *gpioChip) Label() (string, error) {
+	data, err := os.ReadFile(filepath.Join(chip.path, "label"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (chip *gpioChip) ExportRange(startOffset, count int) ([]Node, error) {
+	first, err := chip.FirstGpioNumber()
+	if err != nil {
+		return nil, err
+	}
+	available, err := chip.GpioCount()
+	if err != nil {
+		return nil, err
+	}
+	if startOffset < 0 || count < 0 || startOffset+count > available {
+		return nil, fmt.Errorf("%w: chip has no GPIOs at offsets %d to %d", ErrInvalidPinNumber, startOffset, startOffset+count-1)
+	}
+
+	nodes := make([]Node, 0, count)
+	for offset := startOffset; offset < startOffset+count; offset++ {
+		node := MakeNode(first + offset)
+		err := node.Export()
+		if err != nil {
+			return nodes, &BulkError{Number: node.Number(), Err: err}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// BulkError is returned when an operation on several GPIOs fails part way
+// through, and describes the GPIO on which it failed.
+type BulkError struct {
+	// Number is the GPIO number on which the operation failed.
+	Number int
+
+	// Err is the error that caused the failure.
+	Err error
+}
+
+func (err *BulkError) Error() string {
+	return fmt.Sprintf("GPIO %d: %s", err.Number, err.Err)
+}
+
+func (err *BulkError) Unwrap() error {
+	return err.Err
+}
+
+func readSysfsInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
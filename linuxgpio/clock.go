@@ -0,0 +1,68 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+func (pin *gpioPin) GenerateClock(freq float64, ctx context.Context) error {
+	halfPeriod := time.Duration(5e8 / freq)
+	if freq <= 0 || halfPeriod <= 0 {
+		return fmt.Errorf("invalid clock frequency %g", freq)
+	}
+
+	dir, err := pin.readFile("direction")
+	if err != nil {
+		return err
+	}
+	if dir != "out" {
+		return fmt.Errorf("GPIO %d is not configured as an output", pin.Number())
+	}
+
+	ticker := time.NewTicker(halfPeriod)
+	defer ticker.Stop()
+
+	value := gpio.High
+	for {
+		err := pin.SetValue(value)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err := pin.SetValue(gpio.Low)
+			if err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+
+		if value == gpio.High {
+			value = gpio.Low
+		} else {
+			value = gpio.High
+		}
+	}
+}
+
+func (pin *gpioPin) Pulse(high time.Duration, low time.Duration) error {
+	err := pin.SetValue(gpio.High)
+	if err != nil {
+		return err
+	}
+	time.Sleep(high)
+
+	err = pin.SetValue(gpio.Low)
+	if err != nil {
+		return err
+	}
+	time.Sleep(low)
+
+	return nil
+}
@@ -0,0 +1,195 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type coalescingPin struct {
+	Pin
+	window time.Duration
+	edges  chan struct{}
+
+	// ctx is cancelled when the pin is closed, to stop the background
+	// goroutine.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards closed and run.
+	mu     sync.Mutex
+	closed bool
+	run    *watchRun
+}
+
+// watchRun tracks one run of a coalescingPin's background goroutine.
+type watchRun struct {
+	// done is closed when the goroutine exits, either due to an error from
+	// the underlying pin or because the pin was closed, after which err is
+	// the error it encountered.
+	done chan struct{}
+	err  error
+}
+
+// CoalesceEdges wraps the given pin so that each burst of edges is reported
+// as a single edge by the edge-waiting methods WaitForEdge,
+// WaitForEdgeContext, WaitForEdgeTimeout, WaitForEdgeSlice and WatchEdges.
+// All other methods, including those that count or measure edges, such as
+// CountEdges, are passed through to the underlying pin and must not be used
+// once waiting has begun.
+//
+// A burst ends once no further edge has been seen for the given window, so
+// waiting on the returned pin returns only after the input has been quiet
+// for at least that long. This is useful for smoothing out noisy inputs,
+// such as mechanical switches, where only the final state after a transition
+// is of interest.
+//
+// The returned pin waits for edges on the underlying pin from a background
+// goroutine, started the first time one of the edge-waiting methods is
+// called and stopped when the returned pin is closed. If the goroutine
+// stops because of an error, such as ErrEdgeNotConfigured, that error is
+// returned to any waiting callers and the goroutine is started again by
+// the next call.
+func CoalesceEdges(pin Pin, window time.Duration) Pin {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &coalescingPin{
+		Pin:    pin,
+		window: window,
+		edges:  make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (pin *coalescingPin) WaitForEdge() error {
+	return pin.WaitForEdgeContext(context.Background())
+}
+
+func (pin *coalescingPin) WaitForEdgeContext(ctx context.Context) error {
+	run := pin.watcher()
+
+	select {
+	case <-pin.edges:
+	case <-run.done:
+		return run.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(pin.window)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-pin.edges:
+			timer.Reset(pin.window)
+		case <-timer.C:
+			return nil
+		case <-run.done:
+			return run.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (pin *coalescingPin) WaitForEdgeTimeout(d time.Duration) (bool, error) {
+	return waitForEdgeTimeout(pin, d)
+}
+
+func (pin *coalescingPin) WaitForEdgeSlice(n int, ctx context.Context) ([]EdgeEvent, error) {
+	return waitForEdgeSlice(pin, n, ctx)
+}
+
+func (pin *coalescingPin) WatchEdges(ctx context.Context) (<-chan EdgeEvent, error) {
+	sensitivity, err := pin.Pin.ReadEdgeSensitivity()
+	if err != nil {
+		return nil, err
+	}
+	if sensitivity == gpio.NoEdges {
+		return nil, ErrEdgeNotConfigured
+	}
+	// The thread setup requested by the underlying pin's options is applied
+	// in watch, where the underlying pin is actually waited on.
+	return watchEdges(pin, ctx, nil)
+}
+
+func (pin *coalescingPin) Close() error {
+	pin.cancel()
+
+	// Once closed is set no new goroutine can start, so we need only wait
+	// for any current one to exit, so that it can't use the underlying pin
+	// after it's closed.
+	pin.mu.Lock()
+	pin.closed = true
+	run := pin.run
+	pin.mu.Unlock()
+	if run != nil {
+		<-run.done
+	}
+
+	return pin.Pin.Close()
+}
+
+// watcher starts the background goroutine if it isn't already running,
+// and returns the run it belongs to.
+func (pin *coalescingPin) watcher() *watchRun {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	if pin.closed {
+		run := &watchRun{done: make(chan struct{}), err: os.ErrClosed}
+		close(run.done)
+		return run
+	}
+	if pin.run != nil {
+		select {
+		case <-pin.run.done:
+			// The previous run failed, perhaps because the underlying
+			// pin's edge sensitivity hadn't been set yet, so we try again.
+		default:
+			return pin.run
+		}
+	}
+
+	pin.run = &watchRun{done: make(chan struct{})}
+	go pin.watch(pin.run)
+	return pin.run
+}
+
+func (pin *coalescingPin) watch(run *watchRun) {
+	if setup := threadSetup(pin.Pin); setup != nil {
+		// As with watchEdges, the thread is discarded when we exit, since
+		// setup may have changed its attributes.
+		runtime.LockOSThread()
+		err := setup()
+		if err != nil {
+			run.err = err
+			close(run.done)
+			return
+		}
+	}
+
+	for {
+		err := pin.Pin.WaitForEdgeContext(pin.ctx)
+		if err == nil {
+			select {
+			case pin.edges <- struct{}{}:
+				continue
+			case <-pin.ctx.Done():
+			}
+		}
+
+		if pin.ctx.Err() != nil {
+			err = os.ErrClosed
+		}
+		run.err = err
+		close(run.done)
+		return
+	}
+}
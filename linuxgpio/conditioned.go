@@ -0,0 +1,65 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+// ConditionedPin wraps a Pin so that reading its value samples the
+// underlying pin several times and returns the majority value, acting as a
+// simple software low-pass filter for noisy inputs. All other methods are
+// passed through to the underlying pin.
+type ConditionedPin struct {
+	Pin
+	samples int
+	spacing time.Duration
+}
+
+// NewConditionedPin wraps the given pin so that each call to Value takes the
+// given number of samples, waiting for the given spacing between each, and
+// returns whichever value was seen most often. If the samples are evenly
+// split, the value of the last sample wins.
+//
+// Value therefore blocks for at least (samples-1)*spacing, and a sample count
+// of less than one is treated as one.
+func NewConditionedPin(pin Pin, samples int, spacing time.Duration) *ConditionedPin {
+	if samples < 1 {
+		samples = 1
+	}
+	return &ConditionedPin{
+		Pin:     pin,
+		samples: samples,
+		spacing: spacing,
+	}
+}
+
+func (pin *ConditionedPin) Value() (gpio.Value, error) {
+	var value gpio.Value
+	highs := 0
+	for i := 0; i < pin.samples; i++ {
+		if i > 0 {
+			time.Sleep(pin.spacing)
+		}
+
+		var err error
+		value, err = pin.Pin.Value()
+		if err != nil {
+			return 0, err
+		}
+		if value == gpio.High {
+			highs++
+		}
+	}
+
+	lows := pin.samples - highs
+	switch {
+	case highs > lows:
+		return gpio.High, nil
+	case lows > highs:
+		return gpio.Low, nil
+	default:
+		return value, nil
+	}
+}
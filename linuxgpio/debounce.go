@@ -0,0 +1,131 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+// Debounce wraps a Pin so that its edge-waiting methods report only stable
+// transitions, such as the press or release of a mechanical button that
+// produces many edges as its contacts bounce.
+//
+// An edge is reported once the input has been quiet for the debounce window
+// and has settled at a value that matches the underlying pin's edge
+// sensitivity: high for gpio.RisingEdge, low for gpio.FallingEdge, or, for
+// gpio.BothEdges, a different value than after the previously reported
+// edge. A brief glitch that returns to the original value is therefore
+// ignored entirely. Unlike CoalesceEdges, Debounce waits for edges only
+// while one of its methods is running, and needs no background goroutine.
+//
+// The methods debounced in this way are WaitForEdge, WaitForEdgeContext,
+// WaitForEdgeTimeout, WaitForEdgeSlice and WatchEdges. All other methods,
+// including those that count or measure edges, such as CountEdges, are
+// passed through to the underlying pin and so see every edge.
+type Debounce struct {
+	Pin
+	window time.Duration
+
+	stable      gpio.Value
+	stableKnown bool
+}
+
+// NewDebounce wraps the given pin, which must already have its edge
+// sensitivity configured, so that edges closer together than the given
+// window are treated as a single transition.
+func NewDebounce(pin Pin, window time.Duration) *Debounce {
+	return &Debounce{Pin: pin, window: window}
+}
+
+func (d *Debounce) WaitForEdge() error {
+	return d.WaitForEdgeContext(context.Background())
+}
+
+// WaitForEdgeContext is like WaitForEdge, but returns ctx.Err() early if
+// the given context is cancelled before a stable transition is detected.
+func (d *Debounce) WaitForEdgeContext(ctx context.Context) error {
+	sensitivity, err := d.Pin.ReadEdgeSensitivity()
+	if err != nil {
+		return err
+	}
+
+	if !d.stableKnown {
+		value, err := d.Pin.Value()
+		if err != nil {
+			return err
+		}
+		d.stable, d.stableKnown = value, true
+	}
+
+	for {
+		err := d.Pin.WaitForEdgeContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Wait out the bounce: keep waiting until a whole window passes
+		// with no further edge.
+		for {
+			quietCtx, cancel := context.WithTimeout(ctx, d.window)
+			err := d.Pin.WaitForEdgeContext(quietCtx)
+			cancel()
+			if err == nil {
+				continue
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != context.DeadlineExceeded {
+				return err
+			}
+			break
+		}
+
+		value, err := d.Pin.Value()
+		if err != nil {
+			return err
+		}
+		changed := value != d.stable
+		d.stable = value
+
+		// With only one edge enabled, the kernel doesn't tell us about
+		// the transitions in the other direction, so we can't rely on
+		// having seen the previous one and must instead look at the
+		// level the input settled at.
+		switch sensitivity {
+		case gpio.RisingEdge:
+			if value == gpio.High {
+				return nil
+			}
+		case gpio.FallingEdge:
+			if value == gpio.Low {
+				return nil
+			}
+		default:
+			if changed {
+				return nil
+			}
+		}
+	}
+}
+
+func (d *Debounce) WaitForEdgeTimeout(timeout time.Duration) (bool, error) {
+	return waitForEdgeTimeout(d, timeout)
+}
+
+func (d *Debounce) WaitForEdgeSlice(n int, ctx context.Context) ([]EdgeEvent, error) {
+	return waitForEdgeSlice(d, n, ctx)
+}
+
+func (d *Debounce) WatchEdges(ctx context.Context) (<-chan EdgeEvent, error) {
+	sensitivity, err := d.Pin.ReadEdgeSensitivity()
+	if err != nil {
+		return nil, err
+	}
+	if sensitivity == gpio.NoEdges {
+		return nil, ErrEdgeNotConfigured
+	}
+	return watchEdges(d, ctx, threadSetup(d.Pin))
+}
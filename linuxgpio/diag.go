@@ -0,0 +1,38 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// EpollFdCount returns the number of epoll file descriptors currently open
+// in this process, or -1 if they cannot be counted.
+//
+// Each open Pin holds one epoll file descriptor, so this is intended for
+// integration tests and leak detection tools that want to verify that all
+// pins have been closed. It counts every epoll descriptor in the process,
+// including those used by the Go runtime's network poller, so callers
+// should compare counts taken before and after the code under test rather
+// than expecting any particular value.
+func EpollFdCount() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	count := 0
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", entry.Name()))
+		if err != nil {
+			// The descriptor may have been closed since we listed the
+			// directory, including the one used to read it.
+			continue
+		}
+		if target == "anon_inode:[eventpoll]" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,74 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+)
+
+// FrequencyDivider toggles an output pin once for every given number of
+// rising edges on an input pin, so that the output is a square wave at the
+// input frequency divided by twice the divisor.
+type FrequencyDivider struct {
+	input   Pin
+	output  Pin
+	divisor int
+}
+
+// NewFrequencyDivider creates a divider between the given pins. The input
+// must be configured as an input and the output as an output; the input's
+// edge sensitivity is changed to gpio.RisingEdge.
+func NewFrequencyDivider(input, output Pin, divisor int) (*FrequencyDivider, error) {
+	if divisor < 1 {
+		return nil, fmt.Errorf("invalid frequency divisor %d", divisor)
+	}
+
+	err := input.SetupEdgeWaiting(gpio.RisingEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FrequencyDivider{
+		input:   input,
+		output:  output,
+		divisor: divisor,
+	}, nil
+}
+
+// Run divides the input until the given context is cancelled, at which
+// point it returns ctx.Err(), or until an operation on either pin fails.
+//
+// Edges that arrive faster than this process can handle them are merged by
+// the kernel, and so are counted only once.
+func (d *FrequencyDivider) Run(ctx context.Context) error {
+	value, err := d.output.Value()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for {
+		err := d.input.WaitForEdgeContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		count++
+		if count < d.divisor {
+			continue
+		}
+		count = 0
+
+		if value == gpio.High {
+			value = gpio.Low
+		} else {
+			value = gpio.High
+		}
+		err = d.output.SetValue(value)
+		if err != nil {
+			return err
+		}
+	}
+}
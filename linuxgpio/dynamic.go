@@ -0,0 +1,187 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DynamicGpioNode is a Node that identifies its GPIO by the label of the
+// chip that provides it and its offset within that chip, rather than by a
+// fixed GPIO number. This is useful on systems where GPIO chips may be
+// numbered differently on each boot.
+//
+// The GPIO number is found on first use and then remembered. If the chips
+// may have been renumbered since, such as after a driver is reloaded, call
+// Invalidate to find it again on next use. Failures to find the GPIO are not
+// remembered.
+type DynamicGpioNode struct {
+	chipLabel string
+	offset    int
+
+	mu   sync.Mutex
+	once *sync.Once
+	node Node
+	err  error
+}
+
+// NewDynamicGpioNode returns a node for the GPIO at the given offset within
+// the chip with the given label.
+func NewDynamicGpioNode(chipLabel string, offset int) *DynamicGpioNode {
+	return &DynamicGpioNode{
+		chipLabel: chipLabel,
+		offset:    offset,
+		once:      new(sync.Once),
+	}
+}
+
+// Invalidate forgets the GPIO number found previously, if any, so that it
+// will be found again on next use.
+func (d *DynamicGpioNode) Invalidate() {
+	d.mu.Lock()
+	d.once = new(sync.Once)
+	d.mu.Unlock()
+}
+
+// resolve returns a node for the GPIO's current number.
+func (d *DynamicGpioNode) resolve() (Node, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.once.Do(func() {
+		d.node, d.err = d.find()
+	})
+	if d.err != nil {
+		d.once = new(sync.Once)
+	}
+	return d.node, d.err
+}
+
+func (d *DynamicGpioNode) find() (Node, error) {
+	chip, err := FindChipByLabel(d.chipLabel)
+	if err != nil {
+		return nil, err
+	}
+	first, err := chip.FirstGpioNumber()
+	if err != nil {
+		return nil, err
+	}
+	count, err := chip.GpioCount()
+	if err != nil {
+		return nil, err
+	}
+	if d.offset < 0 || d.offset >= count {
+		return nil, fmt.Errorf("%w: chip %q has no GPIO at offset %d", ErrInvalidPinNumber, d.chipLabel, d.offset)
+	}
+	return MakeNode(first + d.offset), nil
+}
+
+// Number returns the GPIO's current number, or -1 if it cannot be found.
+func (d *DynamicGpioNode) Number() int {
+	node, err := d.resolve()
+	if err != nil {
+		return -1
+	}
+	return node.Number()
+}
+
+// Exported returns false if the GPIO cannot be found.
+func (d *DynamicGpioNode) Exported() bool {
+	node, err := d.resolve()
+	if err != nil {
+		return false
+	}
+	return node.Exported()
+}
+
+func (d *DynamicGpioNode) Export() error {
+	node, err := d.resolve()
+	if err != nil {
+		return err
+	}
+	return node.Export()
+}
+
+func (d *DynamicGpioNode) ExportIfNecessary() (bool, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return false, err
+	}
+	return node.ExportIfNecessary()
+}
+
+func (d *DynamicGpioNode) Unexport() error {
+	node, err := d.resolve()
+	if err != nil {
+		return err
+	}
+	return node.Unexport()
+}
+
+func (d *DynamicGpioNode) WaitForUnexport(ctx context.Context) error {
+	node, err := d.resolve()
+	if err != nil {
+		return err
+	}
+	return node.WaitForUnexport(ctx)
+}
+
+func (d *DynamicGpioNode) WaitForExport(ctx context.Context) error {
+	node, err := d.resolve()
+	if err != nil {
+		return err
+	}
+	return node.WaitForExport(ctx)
+}
+
+func (d *DynamicGpioNode) MapAttributes() (map[string]string, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return node.MapAttributes()
+}
+
+func (d *DynamicGpioNode) IsSystemReserved() (bool, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return false, err
+	}
+	return node.IsSystemReserved()
+}
+
+func (d *DynamicGpioNode) Subsystem() (string, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return "", err
+	}
+	return node.Subsystem()
+}
+
+// Open opens the GPIO at its current number. The returned pin's Node method
+// returns a node for that fixed number, so it is unaffected by Invalidate.
+func (d *DynamicGpioNode) Open(options ...PinOption) (Pin, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return node.Open(options...)
+}
+
+func (d *DynamicGpioNode) OpenWith(opts OpenOptions) (Pin, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return node.OpenWith(opts)
+}
+
+func (d *DynamicGpioNode) OpenWithContext(ctx context.Context, options ...PinOption) (Pin, error) {
+	node, err := d.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return node.OpenWithContext(ctx, options...)
+}
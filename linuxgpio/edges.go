@@ -0,0 +1,332 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"errors"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrEdgeNotConfigured is returned when waiting for an edge on a pin whose
+// edge sensitivity was not already set when the pin was opened and has not
+// since been set via SetupEdgeWaiting or SetSensitivity.
+var ErrEdgeNotConfigured = errors.New("edge sensitivity not configured for GPIO")
+
+// EdgeEvent describes an edge detected on a pin.
+type EdgeEvent struct {
+	// Timestamp is the time at which the edge was reported to this process,
+	// which may be some time after it actually occurred.
+	Timestamp time.Time
+
+	// PinNumber is the GPIO number of the pin on which the edge occurred.
+	PinNumber int
+
+	// Value is the value of the pin as read just after the edge was
+	// reported. A rapid series of edges may cause this to differ from the
+	// value that the edge itself produced.
+	Value gpio.Value
+}
+
+func (pin *gpioPin) WaitForEdgeTimeout(d time.Duration) (bool, error) {
+	if !pin.edgeConfigured {
+		return false, ErrEdgeNotConfigured
+	}
+
+	deadline := time.Now().Add(d)
+	for {
+		// Round up, so that we never give up before the deadline.
+		remaining := time.Until(deadline)
+		timeout := int((remaining + time.Millisecond - 1) / time.Millisecond)
+		if timeout < 0 {
+			timeout = 0
+		}
+
+		n, err := syscall.EpollWait(pin.epollFd, pin.epollEvents[:], timeout)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return n > 0, nil
+	}
+}
+
+func (pin *gpioPin) WaitForEdgeSlice(n int, ctx context.Context) ([]EdgeEvent, error) {
+	return waitForEdgeSlice(pin, n, ctx)
+}
+
+// waitForEdgeSlice implements WaitForEdgeSlice in terms of the given pin's
+// WaitForEdgeContext method, so that wrappers which change how edges are
+// detected can reuse it.
+func waitForEdgeSlice(pin Pin, n int, ctx context.Context) ([]EdgeEvent, error) {
+	events := make([]EdgeEvent, 0, n)
+	for len(events) < n {
+		err := pin.WaitForEdgeContext(ctx)
+		if err != nil {
+			return events, err
+		}
+
+		event, err := edgeEvent(pin)
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// waitForEdgeTimeout implements WaitForEdgeTimeout in terms of the given
+// pin's WaitForEdgeContext method, for use by wrappers.
+func waitForEdgeTimeout(pin Pin, d time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	err := pin.WaitForEdgeContext(ctx)
+	if err == context.DeadlineExceeded {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (pin *gpioPin) FlushEdges() error {
+	_, err := pin.Drain()
+	return err
+}
+
+func (pin *gpioPin) Drain() (int, error) {
+	// epoll reports a pending edge on the value file once, so we poll
+	// without blocking until it is no longer reported. The set may also
+	// contain cancellation pipes belonging to concurrent waits, which we
+	// must not wait to be cleared.
+	valueFd := int32(pin.valueFile.Fd())
+	var events [2]syscall.EpollEvent
+	count := 0
+	for {
+		n, err := syscall.EpollWait(pin.epollFd, events[:], 0)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+
+		flushed := false
+		for _, event := range events[:n] {
+			if event.Fd == valueFd {
+				flushed = true
+				count++
+			}
+		}
+		if !flushed {
+			return count, nil
+		}
+	}
+}
+
+func (pin *gpioPin) RawEpollEvents() []syscall.EpollEvent {
+	events := make([]syscall.EpollEvent, 8)
+	for {
+		n, err := syscall.EpollWait(pin.epollFd, events, 0)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || n == 0 {
+			return nil
+		}
+		return events[:n]
+	}
+}
+
+func (pin *gpioPin) WatchEdges(ctx context.Context) (<-chan EdgeEvent, error) {
+	if !pin.edgeConfigured {
+		return nil, ErrEdgeNotConfigured
+	}
+	return watchEdges(pin, ctx, pin.threadSetup())
+}
+
+// watchEdges implements WatchEdges in terms of the given pin's
+// WaitForEdgeContext method, for use by wrappers.
+//
+// If setup is not nil, the goroutine locks itself to its operating system
+// thread and calls setup before waiting for any edges, and WatchEdges
+// fails if setup does. The thread is then discarded when the goroutine
+// exits, since setup may have changed its attributes.
+func watchEdges(pin Pin, ctx context.Context, setup func() error) (<-chan EdgeEvent, error) {
+	ch := make(chan EdgeEvent)
+	ready := make(chan error)
+	go func() {
+		if setup != nil {
+			runtime.LockOSThread()
+			err := setup()
+			if err != nil {
+				ready <- err
+				return
+			}
+		}
+		close(ready)
+
+		defer close(ch)
+		for {
+			err := pin.WaitForEdgeContext(ctx)
+			if err != nil {
+				return
+			}
+			event, err := edgeEvent(pin)
+			if err != nil {
+				return
+			}
+
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := <-ready
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// WaitForAnyEdge waits for an edge on any of the given pins, returning the
+// index of the pin on which it occurred along with a description of the
+// edge. If an error occurs while waiting on one of the pins, returns the
+// index of that pin along with the error. If the given context is cancelled
+// first, returns -1 and ctx.Err().
+//
+// Each pin is waited on by its own goroutine, all of which have exited by
+// the time this function returns. Only one edge is reported even if several
+// pins detect edges at about the same time, in which case the edges on the
+// other pins may be lost.
+func WaitForAnyEdge(ctx context.Context, pins ...Pin) (int, EdgeEvent, error) {
+	waitCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	type result struct {
+		index int
+		event EdgeEvent
+		err   error
+	}
+	results := make(chan result, len(pins))
+
+	for i, pin := range pins {
+		wg.Add(1)
+		go func(i int, pin Pin) {
+			defer wg.Done()
+			events, err := pin.WaitForEdgeSlice(1, waitCtx)
+			r := result{index: i, err: err}
+			if len(events) > 0 {
+				r.event = events[0]
+			}
+			results <- r
+		}(i, pin)
+	}
+
+	select {
+	case r := <-results:
+		if r.err != nil && ctx.Err() != nil {
+			return -1, EdgeEvent{}, ctx.Err()
+		}
+		return r.index, r.event, r.err
+	case <-ctx.Done():
+		return -1, EdgeEvent{}, ctx.Err()
+	}
+}
+
+// EdgeChannel starts a goroutine that waits for edges on the given pin and
+// sends an EdgeEvent for each one to the returned channel, which has a
+// buffer of the given size. This allows edges to be waited for in a select
+// statement alongside other event sources.
+//
+// Events are never allowed to block the goroutine, so any event that arrives
+// while the buffer is full is dropped. With a buffer size of zero, an event
+// is delivered only if a receiver is already waiting for it, so a slow
+// consumer will miss events.
+//
+// The channel is closed once the given context is cancelled or an error
+// occurs while waiting. The pin should not be used to wait for edges
+// elsewhere until then.
+func EdgeChannel(pin Pin, ctx context.Context, buf int) <-chan EdgeEvent {
+	ch := make(chan EdgeEvent, buf)
+	go func() {
+		defer close(ch)
+		for {
+			events, err := pin.WaitForEdgeSlice(1, ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- events[0]:
+			default:
+			}
+		}
+	}()
+	return ch
+}
+
+// edgeEvent produces an EdgeEvent describing an edge that was just reported.
+func edgeEvent(pin Pin) (EdgeEvent, error) {
+	event := EdgeEvent{
+		Timestamp: time.Now(),
+		PinNumber: pin.Number(),
+	}
+
+	var err error
+	event.Value, err = pin.Value()
+	return event, err
+}
+
+func (pin *gpioPin) WaitForEdgeContext(ctx context.Context) error {
+	if !pin.edgeConfigured {
+		return ErrEdgeNotConfigured
+	}
+	if ctx.Done() == nil {
+		// Context can never be cancelled, so we can just block.
+		return pin.WaitForEdge()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cancelled, err := newCancelPipe(ctx, pin.epollFd)
+	if err != nil {
+		return err
+	}
+	defer cancelled.close()
+
+	valueFd := int32(pin.valueFile.Fd())
+	var events [2]syscall.EpollEvent
+	for {
+		n, err := syscall.EpollWait(pin.epollFd, events[:], -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events[:n] {
+			switch event.Fd {
+			case valueFd:
+				return nil
+			case cancelled.fd():
+				return ctx.Err()
+			}
+			// Any other file descriptor belongs to a concurrent call
+			// on the same pin, so we ignore it.
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// These errors describe common reasons for failing to export, unexport or
+// open a GPIO. Errors returned by those operations wrap whichever of these
+// applies, as well as the underlying error from the kernel, and so can be
+// recognized using errors.Is.
+var (
+	ErrNotExported      = errors.New("GPIO not exported")
+	ErrAlreadyExported  = errors.New("GPIO already exported")
+	ErrInvalidPinNumber = errors.New("invalid GPIO number")
+	ErrPermissionDenied = errors.New("permission denied on GPIO sysfs")
+)
+
+// gpioError annotates an error from the given action on the given GPIO. If
+// the error is caused by one of the given errnos, it also wraps the
+// corresponding sentinel error. Permission errors always wrap
+// ErrPermissionDenied.
+func gpioError(action string, number int, err error, sentinels map[syscall.Errno]error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%s GPIO %d: %w: %w", action, number, ErrPermissionDenied, err)
+	}
+	for errno, sentinel := range sentinels {
+		if errors.Is(err, errno) {
+			return fmt.Errorf("%s GPIO %d: %w: %w", action, number, sentinel, err)
+		}
+	}
+	return fmt.Errorf("%s GPIO %d: %w", action, number, err)
+}
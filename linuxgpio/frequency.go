@@ -0,0 +1,69 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"errors"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+func (pin *gpioPin) MeasureFrequency(ctx context.Context, window time.Duration) (float64, error) {
+	count, elapsed, err := pin.countEdges(ctx, gpio.RisingEdge, window)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, errors.New("no edges detected within the measurement window")
+	}
+	return float64(count) / elapsed.Seconds(), nil
+}
+
+func (pin *gpioPin) CountEdges(ctx context.Context, sensitivity gpio.EdgeSensitivity, window time.Duration) (int, error) {
+	count, _, err := pin.countEdges(ctx, sensitivity, window)
+	return count, err
+}
+
+// countEdges counts edges of the given sensitivity for the given window,
+// returning the count and the time actually spent counting. The pin's
+// previous edge sensitivity is restored before returning.
+func (pin *gpioPin) countEdges(ctx context.Context, sensitivity gpio.EdgeSensitivity, window time.Duration) (count int, elapsed time.Duration, err error) {
+	previous, err := pin.ReadEdgeSensitivity()
+	if err != nil {
+		return 0, 0, err
+	}
+	err = pin.SetSensitivity(sensitivity)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		restoreErr := pin.SetSensitivity(previous)
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	err = pin.FlushEdges()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	windowCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	start := time.Now()
+	for {
+		err := pin.WaitForEdgeContext(windowCtx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return count, time.Since(start), ctxErr
+			}
+			if err == context.DeadlineExceeded {
+				return count, time.Since(start), nil
+			}
+			return count, time.Since(start), err
+		}
+		count++
+	}
+}
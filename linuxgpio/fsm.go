@@ -0,0 +1,83 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+)
+
+// State identifies one state of an FSM.
+type State string
+
+type transition struct {
+	sensitivity gpio.EdgeSensitivity
+	handler     func(gpio.Value) State
+}
+
+// FSM is a finite state machine driven by edges on a single GPIO.
+//
+// Each state may have a handler registered with On, which is called whenever
+// an edge of the given sensitivity is detected while the machine is in that
+// state. The handler receives the value of the pin as read just after the
+// edge, and returns the state to move to next.
+type FSM struct {
+	initial     State
+	transitions map[State]transition
+}
+
+// NewFSM creates a new state machine that will begin in the given state
+// when run.
+func NewFSM(initial State) *FSM {
+	return &FSM{
+		initial:     initial,
+		transitions: make(map[State]transition),
+	}
+}
+
+// On registers the handler for the given state, replacing any handler
+// previously registered for it. While in that state, the machine will
+// configure the pin for the given edge sensitivity and wait for an edge.
+//
+// A state with no handler is a final state: Run returns as soon as it is
+// reached.
+func (fsm *FSM) On(state State, sensitivity gpio.EdgeSensitivity, handler func(gpio.Value) State) {
+	fsm.transitions[state] = transition{
+		sensitivity: sensitivity,
+		handler:     handler,
+	}
+}
+
+// Run drives the state machine from its initial state using edges on the
+// given pin, which must be configured as an input. It returns nil once a
+// final state is reached, or an error if the given context is cancelled or
+// if any operation on the pin fails.
+//
+// The pin should not be used to wait for edges elsewhere while Run is in
+// progress.
+func (fsm *FSM) Run(ctx context.Context, pin Pin) error {
+	state := fsm.initial
+	for {
+		t, ok := fsm.transitions[state]
+		if !ok {
+			return nil
+		}
+
+		err := pin.SetSensitivity(t.sensitivity)
+		if err != nil {
+			return err
+		}
+
+		err = pin.WaitForEdgeContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		value, err := pin.Value()
+		if err != nil {
+			return err
+		}
+
+		state = t.handler(value)
+	}
+}
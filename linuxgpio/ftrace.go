@@ -0,0 +1,189 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ftraceDirs are the places where the kernel's tracing filesystem may be
+// mounted, in order of preference.
+var ftraceDirs = []string{"/sys/kernel/debug/tracing", "/sys/kernel/tracing"}
+
+// FtraceGpioEvent describes a GPIO value being set, as reported by the
+// kernel's gpio_value trace event.
+type FtraceGpioEvent struct {
+	Number    int
+	Value     int
+	Timestamp time.Time
+}
+
+// FtraceGpioLog reads the kernel's record of GPIO values being set, using
+// ftrace. Unlike waiting for edges, this reports changes made by any process
+// or kernel driver, with timestamps taken in the kernel at the moment of
+// each change.
+//
+// This requires root privileges and a kernel with tracing enabled. Only one
+// reader of the trace pipe receives each event, so only one FtraceGpioLog
+// should be in use on a system at a time, and it will also take events away
+// from any other tool reading the trace pipe, such as trace-cmd.
+type FtraceGpioLog struct {
+	dir    string
+	pipe   *os.File
+	reader *bufio.Reader
+
+	// bootTime is the wall-clock time corresponding to a trace timestamp
+	// of zero.
+	bootTime time.Time
+}
+
+// OpenFtraceGpioLog enables the gpio_value trace event and begins reading
+// the trace pipe. The caller must call Close when done, to disable the
+// event again.
+func OpenFtraceGpioLog() (*FtraceGpioLog, error) {
+	var dir string
+	for _, candidate := range ftraceDirs {
+		if _, err := os.Stat(filepath.Join(candidate, "trace_pipe")); err == nil {
+			dir = candidate
+			break
+		}
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("%w: kernel tracing filesystem is not mounted", ErrUnsupported)
+	}
+
+	bootTime, err := monotonicEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	err = setFtraceGpioEnabled(dir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe, err := os.Open(filepath.Join(dir, "trace_pipe"))
+	if err != nil {
+		setFtraceGpioEnabled(dir, false)
+		return nil, err
+	}
+
+	return &FtraceGpioLog{
+		dir:      dir,
+		pipe:     pipe,
+		reader:   bufio.NewReader(pipe),
+		bootTime: bootTime,
+	}, nil
+}
+
+// Next blocks until the next GPIO value is set, and then returns a
+// description of it. Other events in the trace, including reads of GPIO
+// values, are skipped.
+func (l *FtraceGpioLog) Next() (FtraceGpioEvent, error) {
+	for {
+		line, err := l.reader.ReadString('\n')
+		if err != nil {
+			return FtraceGpioEvent{}, err
+		}
+
+		event, ok := l.parse(line)
+		if ok {
+			return event, nil
+		}
+	}
+}
+
+// Close disables the gpio_value trace event and closes the trace pipe.
+//
+// Reads from the trace pipe cannot be interrupted, so a concurrent call to
+// Next may remain blocked until one more trace event arrives.
+func (l *FtraceGpioLog) Close() error {
+	enableErr := setFtraceGpioEnabled(l.dir, false)
+	closeErr := l.pipe.Close()
+	if enableErr != nil {
+		return enableErr
+	}
+	return closeErr
+}
+
+// parse interprets a line from the trace pipe, which for the events we're
+// interested in looks like this:
+//
+//	<idle>-0       [000] d.h1  1234.567890: gpio_value: 23 set 1
+func (l *FtraceGpioLog) parse(line string) (FtraceGpioEvent, bool) {
+	const marker = ": gpio_value: "
+	idx := strings.Index(line, marker)
+	if idx < 0 {
+		return FtraceGpioEvent{}, false
+	}
+
+	prefix := strings.Fields(line[:idx])
+	if len(prefix) == 0 {
+		return FtraceGpioEvent{}, false
+	}
+	seconds, err := strconv.ParseFloat(prefix[len(prefix)-1], 64)
+	if err != nil {
+		return FtraceGpioEvent{}, false
+	}
+
+	fields := strings.Fields(line[idx+len(marker):])
+	if len(fields) != 3 || fields[1] != "set" {
+		return FtraceGpioEvent{}, false
+	}
+	number, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return FtraceGpioEvent{}, false
+	}
+	value, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return FtraceGpioEvent{}, false
+	}
+
+	return FtraceGpioEvent{
+		Number:    number,
+		Value:     value,
+		Timestamp: l.bootTime.Add(time.Duration(seconds * float64(time.Second))),
+	}, true
+}
+
+func setFtraceGpioEnabled(dir string, enabled bool) error {
+	path := filepath.Join(dir, "events", "gpio", "gpio_value", "enable")
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if enabled {
+		_, err = file.WriteString("1\n")
+	} else {
+		_, err = file.WriteString("0\n")
+	}
+	return err
+}
+
+// monotonicEpoch returns the wall-clock time at which the monotonic clock
+// read zero, which is approximately when the system booted. The default
+// trace clock counts from the same point, though it may drift slightly
+// from the monotonic clock over time.
+func monotonicEpoch() (time.Time, error) {
+	var ts syscall.Timespec
+	now := time.Now()
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return time.Time{}, errno
+	}
+	// We strip Go's own monotonic reading, which is meaningless for times
+	// derived from the trace.
+	return now.Round(0).Add(-time.Duration(ts.Nano())), nil
+}
+
+const clockMonotonic = 1
@@ -0,0 +1,112 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"sort"
+)
+
+// GpioGroup reads and writes the values of several pins together, such as
+// the data lines of a parallel bus or the rows of an LED matrix.
+//
+// This is best-effort only: each pin is still read or written by a separate
+// system call, so other processes and the hardware itself will see the pins
+// change one at a time, in the order given, typically a few microseconds
+// apart. What the group does guarantee is that no other goroutine using the
+// same pins, whether directly or via another group, can read or write any
+// of them part-way through.
+type GpioGroup struct {
+	pins []Pin
+
+	// locked are the distinct pins from this package among pins, in the
+	// order in which their locks must be acquired.
+	locked []*gpioPin
+}
+
+// NewGpioGroup creates a group of the given pins, which must all already be
+// opened. Values passed to and returned from the group's methods are in the
+// same order as the given pins.
+func NewGpioGroup(pins []Pin) *GpioGroup {
+	seen := make(map[*gpioPin]bool)
+	var locked []*gpioPin
+	for _, p := range pins {
+		if pin, ok := p.(*gpioPin); ok && !seen[pin] {
+			seen[pin] = true
+			locked = append(locked, pin)
+		}
+	}
+
+	// Acquiring locks in a consistent order prevents deadlock between
+	// groups that share pins. The same GPIO may have been opened more than
+	// once, so we break ties using the value files' descriptors, which are
+	// unique while the pins are open.
+	sort.Slice(locked, func(i, j int) bool {
+		if locked[i].Number() != locked[j].Number() {
+			return locked[i].Number() < locked[j].Number()
+		}
+		return locked[i].valueFile.Fd() < locked[j].valueFile.Fd()
+	})
+
+	return &GpioGroup{
+		pins:   append([]Pin(nil), pins...),
+		locked: locked,
+	}
+}
+
+// SetValues sets the value of each pin in the group to the corresponding
+// given value. If setting any pin fails, the remaining pins are not set.
+func (g *GpioGroup) SetValues(values []gpio.Value) error {
+	if len(values) != len(g.pins) {
+		return fmt.Errorf("got %d values for a group of %d pins", len(values), len(g.pins))
+	}
+
+	for _, pin := range g.locked {
+		pin.mu.Lock()
+	}
+	defer func() {
+		for _, pin := range g.locked {
+			pin.mu.Unlock()
+		}
+	}()
+
+	for i, p := range g.pins {
+		var err error
+		if pin, ok := p.(*gpioPin); ok {
+			err = pin.setValue(values[i])
+		} else {
+			err = p.SetValue(values[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Values returns the values of the pins in the group.
+func (g *GpioGroup) Values() ([]gpio.Value, error) {
+	for _, pin := range g.locked {
+		pin.mu.RLock()
+	}
+	defer func() {
+		for _, pin := range g.locked {
+			pin.mu.RUnlock()
+		}
+	}()
+
+	values := make([]gpio.Value, len(g.pins))
+	for i, p := range g.pins {
+		var err error
+		if pin, ok := p.(*gpioPin); ok {
+			values[i], err = pin.value()
+		} else {
+			values[i], err = p.Value()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
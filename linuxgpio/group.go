@@ -0,0 +1,181 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+)
+
+// EpollGroup waits for edges on any of a group of pins using a single epoll
+// instance, which is more efficient than waiting on each pin from its own
+// goroutine when there are many pins.
+//
+// The pins remain usable individually, but the group should be closed
+// before the pins themselves are closed.
+type EpollGroup struct {
+	epollFd int
+
+	mu   sync.Mutex
+	pins map[int32]*gpioPin
+}
+
+// NewEpollGroup creates an empty group. Pins are added with Add.
+func NewEpollGroup() (*EpollGroup, error) {
+	epollFd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EpollGroup{
+		epollFd: epollFd,
+		pins:    make(map[int32]*gpioPin),
+	}, nil
+}
+
+// Add adds a pin to the group. The pin must have been opened by this
+// package and must already have its edge sensitivity configured.
+//
+// Pins may be added while another goroutine is waiting in Wait.
+func (g *EpollGroup) Add(p Pin) error {
+	pin, ok := p.(*gpioPin)
+	if !ok {
+		return fmt.Errorf("GPIO %d was not opened by this package", p.Number())
+	}
+	if !pin.edgeConfigured {
+		return ErrEdgeNotConfigured
+	}
+
+	// Unlike a pin's own epoll set, we use level-triggered mode here so
+	// that if several pins change at once, those not returned by one call
+	// to Wait will be returned by the next. Reading a value file clears
+	// its pending event. We don't ask for EPOLLIN because sysfs reports
+	// every attribute as always readable.
+	valueFd := int(pin.valueFile.Fd())
+	event := syscall.EpollEvent{
+		Events: syscall.EPOLLPRI | syscall.EPOLLERR,
+		Fd:     int32(valueFd),
+	}
+
+	// We register the pin before adding it to the epoll set so that Wait
+	// can never see an event for a pin it doesn't know about.
+	g.mu.Lock()
+	g.pins[event.Fd] = pin
+	g.mu.Unlock()
+
+	err := syscall.EpollCtl(g.epollFd, syscall.EPOLL_CTL_ADD, valueFd, &event)
+	if err != nil {
+		g.mu.Lock()
+		delete(g.pins, event.Fd)
+		g.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Wait blocks until an edge is detected on any of the group's pins and then
+// returns that pin, or returns ctx.Err() if the given context is cancelled
+// first.
+//
+// The pin's value is read in order to acknowledge the edge, so a caller
+// that needs the value should read it again with Value.
+func (g *EpollGroup) Wait(ctx context.Context) (Pin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var cancelled *cancelPipe
+	if ctx.Done() != nil {
+		var err error
+		cancelled, err = newCancelPipe(ctx, g.epollFd)
+		if err != nil {
+			return nil, err
+		}
+		defer cancelled.close()
+	}
+
+	var events [1]syscall.EpollEvent
+	for {
+		n, err := syscall.EpollWait(g.epollFd, events[:], -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+
+		if cancelled != nil && events[0].Fd == cancelled.fd() {
+			return nil, ctx.Err()
+		}
+
+		g.mu.Lock()
+		pin, ok := g.pins[events[0].Fd]
+		g.mu.Unlock()
+		if !ok {
+			// It's the cancellation pipe of a concurrent call.
+			continue
+		}
+
+		_, err = pin.Value()
+		if err != nil {
+			return nil, err
+		}
+		return pin, nil
+	}
+}
+
+// Close releases the group's epoll instance. It does not close the pins.
+func (g *EpollGroup) Close() error {
+	return syscall.Close(g.epollFd)
+}
+
+// GroupInterruptController waits for edges on a fixed group of pins using a
+// single epoll instance, such as when scanning a keyboard matrix or an
+// array of buttons. It is a convenience wrapper around EpollGroup for when
+// all of the pins are known in advance.
+type GroupInterruptController struct {
+	group *EpollGroup
+}
+
+// NewGroupInterruptController creates a controller for the given pins, each
+// of which must have been opened by this package and must already have its
+// edge sensitivity configured.
+//
+// The pins remain usable individually, but the controller should be closed
+// before the pins themselves are closed.
+func NewGroupInterruptController(pins []Pin) (*GroupInterruptController, error) {
+	group, err := NewEpollGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pin := range pins {
+		err := group.Add(pin)
+		if err != nil {
+			group.Close()
+			return nil, err
+		}
+	}
+
+	return &GroupInterruptController{group: group}, nil
+}
+
+// WaitAny blocks until an edge is detected on any of the controller's pins
+// and then returns that pin, or returns ctx.Err() if the given context is
+// cancelled first.
+//
+// The pin's value is read in order to acknowledge the edge, so a caller
+// that needs the value should read it again with Value.
+func (c *GroupInterruptController) WaitAny(ctx context.Context) (Pin, error) {
+	return c.group.Wait(ctx)
+}
+
+// Close releases the controller's epoll instance. It does not close the pins.
+func (c *GroupInterruptController) Close() error {
+	return c.group.Close()
+}
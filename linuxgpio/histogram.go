@@ -0,0 +1,66 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBuckets is enough buckets for any non-negative time.Duration.
+const histogramBuckets = 64
+
+// LatencyHistogram wraps a Pin and records how long each call to WaitForEdge
+// blocks for, in buckets whose bounds are powers of two nanoseconds. All
+// other methods are passed through to the underlying pin.
+//
+// Comparing the distribution of waits with the expected timing of the
+// input signal can help to show whether delays in handling edges are caused
+// by the kernel, by the Go scheduler, or by the application itself.
+type LatencyHistogram struct {
+	Pin
+	buckets [histogramBuckets]int64
+}
+
+// NewLatencyHistogram wraps the given pin to record the duration of its
+// WaitForEdge calls.
+func NewLatencyHistogram(pin Pin) *LatencyHistogram {
+	return &LatencyHistogram{Pin: pin}
+}
+
+func (h *LatencyHistogram) WaitForEdge() error {
+	start := time.Now()
+	err := h.Pin.WaitForEdge()
+	elapsed := time.Since(start)
+
+	bucket := bits.Len64(uint64(elapsed))
+	if bucket >= histogramBuckets {
+		bucket = histogramBuckets - 1
+	}
+	atomic.AddInt64(&h.buckets[bucket], 1)
+
+	return err
+}
+
+// Histogram returns a copy of the current bucket counts. Bucket 0 counts
+// waits that took no measurable time, and each bucket i after that counts
+// waits that took at least 2^(i-1) and less than 2^i nanoseconds.
+func (h *LatencyHistogram) Histogram() []int64 {
+	result := make([]int64, histogramBuckets)
+	for i := range h.buckets {
+		result[i] = atomic.LoadInt64(&h.buckets[i])
+	}
+	return result
+}
+
+// ResetHistogram sets all of the bucket counts back to zero.
+//
+// It is not called Reset because that would hide the Reset method of the
+// wrapped pin, and since Pin's Reset returns an error, a LatencyHistogram
+// would then no longer implement Pin.
+func (h *LatencyHistogram) ResetHistogram() {
+	for i := range h.buckets {
+		atomic.StoreInt64(&h.buckets[i], 0)
+	}
+}
@@ -0,0 +1,84 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"github.com/apparentlymart/go-gpio/gpio"
+	"sync"
+	"time"
+)
+
+// ValueSample is a value read from a pin, along with the time it was read.
+type ValueSample struct {
+	Value gpio.Value
+	At    time.Time
+}
+
+// HistoricalPin wraps a Pin so that each value successfully read from it is
+// recorded, allowing recent values to be retrieved later with ValueHistory.
+// All other methods are passed through to the underlying pin.
+type HistoricalPin struct {
+	Pin
+
+	mu      sync.Mutex
+	samples []ValueSample
+	next    int
+	full    bool
+}
+
+// NewHistoricalPin wraps the given pin so that it remembers the given number
+// of most recently read values. A capacity of less than one is treated as
+// one.
+func NewHistoricalPin(pin Pin, capacity int) *HistoricalPin {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &HistoricalPin{
+		Pin:     pin,
+		samples: make([]ValueSample, capacity),
+	}
+}
+
+func (pin *HistoricalPin) Value() (gpio.Value, error) {
+	value, err := pin.Pin.Value()
+	if err != nil {
+		return value, err
+	}
+
+	pin.mu.Lock()
+	pin.samples[pin.next] = ValueSample{Value: value, At: time.Now()}
+	pin.next++
+	if pin.next == len(pin.samples) {
+		pin.next = 0
+		pin.full = true
+	}
+	pin.mu.Unlock()
+
+	return value, nil
+}
+
+// ValueHistory returns up to n of the most recently read values, oldest
+// first. Fewer are returned if fewer have been read, or if n is greater than
+// the capacity given to NewHistoricalPin.
+func (pin *HistoricalPin) ValueHistory(n int) []ValueSample {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	count := pin.next
+	if pin.full {
+		count = len(pin.samples)
+	}
+	if n < count {
+		count = n
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	result := make([]ValueSample, count)
+	start := pin.next - count
+	for i := range result {
+		result[i] = pin.samples[(start+i+len(pin.samples))%len(pin.samples)]
+	}
+	return result
+}
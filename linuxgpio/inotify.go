@@ -0,0 +1,80 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"syscall"
+)
+
+// inotifyRecheckMillis is the longest time waitForDirChange will wait before
+// checking its condition again even if no inotify event has arrived.
+//
+// sysfs only generates inotify events for changes made via the filesystem
+// itself, and not for entries the kernel creates or removes on its own
+// behalf, so depending on the kernel version we may never hear about the
+// change we're waiting for. Rechecking periodically makes sure we notice it
+// eventually, while inotify allows us to notice promptly where it works.
+const inotifyRecheckMillis = 100
+
+// waitForDirChange blocks until the given condition function returns true,
+// checking it initially and then again whenever inotify reports one of the
+// events in the given mask for the given directory, and at least every
+// inotifyRecheckMillis. Returns ctx.Err() if the context is cancelled first.
+func waitForDirChange(ctx context.Context, dir string, mask uint32, cond func() bool) error {
+	if cond() {
+		return nil
+	}
+
+	inotifyFd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(inotifyFd)
+
+	_, err = syscall.InotifyAddWatch(inotifyFd, dir, mask)
+	if err != nil {
+		return err
+	}
+
+	epollFd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(epollFd)
+
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(inotifyFd)}
+	err = syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, inotifyFd, &event)
+	if err != nil {
+		return err
+	}
+
+	var events [1]syscall.EpollEvent
+	buf := make([]byte, 4096)
+	for {
+		// The condition may have become true between our first check and
+		// the watch being established, so we check again before waiting.
+		if cond() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := syscall.EpollWait(epollFd, events[:], inotifyRecheckMillis)
+		if err != nil && err != syscall.EINTR {
+			return err
+		}
+
+		if n > 0 {
+			// We don't care about the details of the events, since we'll
+			// just check the condition again, so we just discard them.
+			for {
+				_, err := syscall.Read(inotifyFd, buf)
+				if err != nil {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SetInterruptAffinity restricts the interrupt used to detect edges on the
+// given pin so that it is handled only by the given CPUs, by writing to
+// /proc/irq/<n>/smp_affinity_list. This is typically used in real-time
+// systems to isolate GPIO interrupts from CPUs running latency-sensitive
+// work. Changing interrupt affinity usually requires root privileges.
+//
+// The kernel requests the interrupt only while the pin has an edge
+// sensitivity other than gpio.NoEdges, so SetSensitivity must be called
+// before using this function. The affinity is lost if the sensitivity is
+// later returned to gpio.NoEdges.
+func SetInterruptAffinity(pin Pin, cpus []int) error {
+	irq, err := pinIrq(pin)
+	if err != nil {
+		return err
+	}
+
+	list := make([]string, len(cpus))
+	for i, cpu := range cpus {
+		list[i] = strconv.Itoa(cpu)
+	}
+
+	path := fmt.Sprintf("/proc/irq/%d/smp_affinity_list", irq)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(strings.Join(list, ",") + "\n")
+	return err
+}
+
+// GetInterruptAffinity returns the CPUs that may handle the interrupt used
+// to detect edges on the given pin. As with SetInterruptAffinity, the pin
+// must have an edge sensitivity other than gpio.NoEdges.
+func GetInterruptAffinity(pin Pin) ([]int, error) {
+	irq, err := pinIrq(pin)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/proc/irq/%d/smp_affinity_list", irq)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCPUList(strings.TrimSpace(string(data)))
+}
+
+// parseCPUList parses the kernel's CPU list format, such as "0-2,5", into
+// the individual CPU numbers it represents.
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return cpus, nil
+	}
+
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
+
+// pinIrq finds the interrupt that the kernel requested to detect edges on
+// the given pin.
+//
+// The kernel doesn't expose this mapping directly, so we find the offset of
+// the GPIO within its chip and then look for an interrupt registered by the
+// sysfs GPIO code (whose action is named "gpiolib") with a matching hardware
+// interrupt number. This relies on the common convention that a GPIO chip's
+// hardware interrupt numbers match its line offsets.
+func pinIrq(pin Pin) (int, error) {
+	offset, err := chipOffset(pin.Number())
+	if err != nil {
+		return 0, err
+	}
+
+	dirs, err := filepath.Glob("/sys/kernel/irq/*")
+	if err != nil {
+		return 0, err
+	}
+
+	irq := -1
+	for _, dir := range dirs {
+		actions, err := os.ReadFile(filepath.Join(dir, "actions"))
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(string(actions), "gpiolib") {
+			continue
+		}
+
+		hwirq, err := readSysfsInt(filepath.Join(dir, "hwirq"))
+		if err != nil || hwirq != offset {
+			continue
+		}
+
+		if irq != -1 {
+			return 0, fmt.Errorf("cannot determine which interrupt belongs to GPIO %d", pin.Number())
+		}
+		irq, err = strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if irq == -1 {
+		return 0, fmt.Errorf("no interrupt is configured for GPIO %d", pin.Number())
+	}
+	return irq, nil
+}
+
+// chipOffset returns the offset of the given GPIO number within the GPIO chip
+// that provides it.
+func chipOffset(number int) (int, error) {
+	chips, err := ListGpioChips()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, chip := range chips {
+		first, err := chip.FirstGpioNumber()
+		if err != nil {
+			return 0, err
+		}
+		last, err := chip.LastGpioNumber()
+		if err != nil {
+			return 0, err
+		}
+
+		if number >= first && number <= last {
+			return number - first, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no GPIO chip provides GPIO %d", number)
+}
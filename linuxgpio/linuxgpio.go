@@ -11,15 +11,33 @@
 package linuxgpio
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/apparentlymart/go-gpio/gpio"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // Pin is an extension of gpio.Pin that allows a pin to be closed,
 // unexported, etc.
+//
+// The Value, SetValue, Toggle, SetDirection and SetActiveLow methods of pins
+// from this package are safe to call concurrently from multiple goroutines.
+// Other methods, including those that change the rest of the pin's
+// configuration, are not.
+//
+// The values passed to SetValue and returned by Value are logical values:
+// if the GPIO is configured as active-low, using SetActiveLow, then
+// gpio.High corresponds to a low voltage on the physical line and gpio.Low
+// to a high voltage.
 type Pin interface {
 	gpio.Pin
 	gpio.EdgeWaiter
@@ -29,13 +47,206 @@ type Pin interface {
 
 	// Close will close the file descriptors that have been opened for this
 	// GPIO in sysfs. After this method is called, further use of this instance
-	// will fail.
+	// will fail. Calling Close again has no effect and returns nil.
 	Close() (err error)
 
 	// Node returns the Node object from which this pin was opened.
 	Node() (node Node)
+
+	// SetupEdgeWaiting prepares the pin for use with WaitForEdge and the
+	// other edge-waiting methods by setting its edge sensitivity.
+	//
+	// Waiting for edges on a pin whose sensitivity hasn't been set to
+	// something other than gpio.NoEdges, either with this method or with
+	// SetSensitivity, or by some other means before the pin was opened,
+	// fails with ErrEdgeNotConfigured rather than blocking forever.
+	SetupEdgeWaiting(s gpio.EdgeSensitivity) error
+
+	// WaitForEdgeContext is like WaitForEdge, but returns ctx.Err() early
+	// if the given context is cancelled before an edge is detected.
+	WaitForEdgeContext(ctx context.Context) error
+
+	// WaitForEdgeTimeout is like WaitForEdge, but gives up once the given
+	// duration has passed. Returns true if an edge was detected, or false
+	// if the timeout expired first.
+	WaitForEdgeTimeout(d time.Duration) (bool, error)
+
+	// WaitForEdgeSlice waits for exactly n edges and returns an EdgeEvent
+	// for each of them. If the given context is cancelled before n edges
+	// are seen, returns the events seen so far along with ctx.Err().
+	WaitForEdgeSlice(n int, ctx context.Context) ([]EdgeEvent, error)
+
+	// FlushEdges discards any edges that have been detected but not yet
+	// waited for, so that the next wait blocks until a new edge occurs.
+	FlushEdges() error
+
+	// Drain is like FlushEdges, but also returns the number of pending
+	// edge notifications that were discarded.
+	//
+	// The kernel merges any edges that occur while no wait is in progress
+	// into a single notification, so this is usually either zero or one
+	// regardless of how many edges were actually missed. A non-zero result
+	// indicates only that at least one edge was missed.
+	Drain() (int, error)
+
+	// RawEpollEvents returns the events currently pending on the pin's
+	// epoll instance, without blocking. Returns nil if there are none, or
+	// if the underlying EpollWait call fails.
+	//
+	// This is an escape hatch for callers that need to inspect the event
+	// flags reported by the kernel. Because the pin's value file is
+	// registered in edge-triggered mode, any edge reported here will not be
+	// reported again by WaitForEdge or the other waiting methods. Events
+	// for file descriptors other than the pin's value file may belong to
+	// concurrent waits on the same pin.
+	RawEpollEvents() []syscall.EpollEvent
+
+	// WatchEdges starts a goroutine that waits for edges on the pin and
+	// sends an EdgeEvent for each to the returned channel, until the given
+	// context is cancelled or an error occurs, at which point the channel
+	// is closed. Returns ErrEdgeNotConfigured immediately if the pin's edge
+	// sensitivity has not been set.
+	//
+	// Each event is delivered before the next edge is waited for, so while
+	// the receiver is slow to accept an event, any further edges are merged
+	// into one. The pin should not be used to wait for edges elsewhere
+	// until the channel is closed.
+	WatchEdges(ctx context.Context) (<-chan EdgeEvent, error)
+
+	// WaitForPulse waits for the pin to change to the given value and then
+	// change back again, and returns the time between the two changes. If
+	// the pin already has the given value when called, the current pulse is
+	// ignored and the next one is measured.
+	//
+	// The pin's edge sensitivity is temporarily set to gpio.BothEdges, and
+	// is restored before returning. If the given timeout passes before a
+	// whole pulse is seen, returns context.DeadlineExceeded.
+	//
+	// The measurement includes any scheduling delay in handling each edge,
+	// so it is suitable only for pulses much longer than that delay, which
+	// is typically tens of microseconds.
+	WaitForPulse(polarity gpio.Value, timeout time.Duration) (time.Duration, error)
+
+	// MeasurePulseWidth is like WaitForPulse, but waits until the given
+	// context is cancelled rather than for a fixed timeout, returning
+	// ctx.Err() if a whole pulse hasn't been seen by then.
+	MeasurePulseWidth(ctx context.Context, polarity gpio.Value) (time.Duration, error)
+
+	// MeasureFrequency counts rising edges on the pin for the given window
+	// and returns the frequency they imply, in hertz. Returns an error if
+	// no edges are seen, or ctx.Err() if the given context is cancelled
+	// before the window ends. The pin's edge sensitivity is restored before
+	// returning.
+	//
+	// Edges that arrive before this process has handled the previous one
+	// are merged by the kernel, so the result is accurate only for signals
+	// of a few kilohertz at most.
+	MeasureFrequency(ctx context.Context, window time.Duration) (float64, error)
+
+	// CountEdges counts edges of the given sensitivity on the pin for the
+	// given window, such as the pulses from a flow meter. Returns ctx.Err()
+	// along with the count so far if the given context is cancelled before
+	// the window ends. The pin's edge sensitivity is restored before
+	// returning.
+	//
+	// As with MeasureFrequency, edges that arrive too quickly to be handled
+	// individually are merged, and so counted only once.
+	CountEdges(ctx context.Context, sensitivity gpio.EdgeSensitivity, window time.Duration) (int, error)
+
+	// Reset returns the pin to the kernel's default state for an exported
+	// GPIO: edge sensitivity is set to gpio.NoEdges, an output is driven
+	// low and then switched to an input, and active-low is disabled. This
+	// is useful in cleanup code, to avoid one test or program run affecting
+	// the next.
+	Reset() error
+
+	// Toggle reads the current value of the pin and then sets it to the
+	// opposite value. Concurrent calls to Value, SetValue and Toggle on the
+	// same pin cannot intervene between the read and the write, though of
+	// course other processes still can.
+	Toggle() error
+
+	// SetDirectionOutput configures the GPIO as an output already driven to
+	// the given logical value, in a single write to sysfs. It is like
+	// calling SetDirection with OutLow or OutHigh, except that for an
+	// active-low GPIO the opposite one is used, so that Value then returns
+	// initialValue.
+	SetDirectionOutput(initialValue gpio.Value) error
+
+	// SetActiveLow configures whether the GPIO is active-low, inverting the
+	// meaning of gpio.High and gpio.Low for both input and output, and for
+	// edge detection.
+	SetActiveLow(invert bool) error
+
+	// ReadActiveLow returns whether the GPIO is currently active-low.
+	ReadActiveLow() (bool, error)
+
+	// ReadDirection returns the direction currently configured in the
+	// kernel for the GPIO, which may differ from the one most recently set
+	// via this pin if another process or driver has since changed it.
+	//
+	// The kernel reports only whether the GPIO is an input or an output, so
+	// the result is always either gpio.In or gpio.Out.
+	ReadDirection() (gpio.Direction, error)
+
+	// ReadEdgeSensitivity returns the edge sensitivity currently configured
+	// in the kernel for the GPIO, which may differ from the one most
+	// recently set via this pin if another process has since changed it.
+	ReadEdgeSensitivity() (gpio.EdgeSensitivity, error)
+
+	// GenerateClock toggles the pin at the given frequency in hertz, with a
+	// 50% duty cycle, until the given context is cancelled, and then drives
+	// it low and returns ctx.Err(). Returns an error immediately if the pin
+	// is not configured as an output.
+	//
+	// The timing is only as accurate as the operating system's scheduler
+	// allows, so this is suitable only for slow bit-banged protocols that
+	// tolerate an irregular clock.
+	GenerateClock(freq float64, ctx context.Context) error
+
+	// Pulse drives the pin high for the given duration and then low for
+	// the given duration, such as to trigger an ultrasonic range sensor.
+	// The pin must already be configured as an output.
+	//
+	// As with GenerateClock, the durations are minimums: the operating
+	// system's scheduler may delay the end of either phase, often by tens
+	// of microseconds and occasionally by much more.
+	Pulse(high time.Duration, low time.Duration) error
+
+	// Dir returns the open sysfs directory for this GPIO.
+	//
+	// This is an escape hatch for callers that need to access sysfs
+	// attributes not otherwise exposed by this package, by passing the
+	// directory's file descriptor to syscall.Openat. The returned file is
+	// owned by the pin and must not be closed by the caller; it becomes
+	// invalid once the pin is closed.
+	Dir() *os.File
 }
 
+// OutLow and OutHigh are additional gpio.Direction values understood by
+// the SetDirection method of pins from this package. They are linuxgpio
+// extensions; the upstream go-gpio package does not define them, and other
+// gpio.Pin implementations will not accept them.
+//
+// Each configures the GPIO as an output and drives it to the corresponding
+// level in a single write to sysfs, avoiding the brief glitch that can occur
+// when a pin is switched to output before its value is set.
+//
+// Unlike the values passed to SetValue, these are physical levels: the
+// kernel ignores the active_low setting for them, so OutHigh always drives
+// the line to a high voltage. Use Pin.SetDirectionOutput to set a logical
+// value instead.
+const (
+	OutLow gpio.Direction = 100 + iota
+	OutHigh
+)
+
+// lowData and highData are the byte sequences SetValue writes to the sysfs
+// "value" file. Sharing them is harmless but does not actually save an
+// allocation: neither this approach nor a slice literal at the call site
+// allocates per call, since WriteAt does not retain its argument and so a
+// literal would not escape to the heap. A sync.Pool of buffers would only
+// add overhead.
 var (
 	lowData  []byte
 	highData []byte
@@ -85,9 +296,65 @@ type Node interface {
 	// It is an error to unexport a GPIO that is not already exported.
 	Unexport() (err error)
 
+	// WaitForUnexport blocks until the GPIO is no longer exported, or
+	// until the given context is cancelled. This is useful when monitoring
+	// a GPIO that is managed by another process or by a udev rule.
+	WaitForUnexport(ctx context.Context) error
+
+	// WaitForExport blocks until the GPIO is exported, or until the given
+	// context is cancelled. The kernel may take a moment to create a GPIO's
+	// sysfs directory after it is exported, so this can be used between
+	// Export and Open to avoid Open failing with ErrNotExported.
+	WaitForExport(ctx context.Context) error
+
+	// MapAttributes reads all of the attribute files in the GPIO's sysfs
+	// directory and returns their contents, with trailing whitespace
+	// removed, keyed by filename. This includes attributes that this package
+	// does not otherwise support, such as any specific to a particular
+	// kernel version or driver. Attributes that cannot be read are omitted.
+	//
+	// The GPIO must be exported.
+	MapAttributes() (map[string]string, error)
+
+	// IsSystemReserved returns true if the name of the kernel driver for
+	// the GPIO's controller is one of those in ReservedDrivers, suggesting
+	// that the kernel uses its GPIOs for its own purposes and so they
+	// should not be controlled from userspace.
+	//
+	// The sysfs interface doesn't say which driver, if any, is using an
+	// individual GPIO, so this classifies only the controller: the result
+	// is the same for every GPIO of a given chip.
+	//
+	// The GPIO must be exported.
+	IsSystemReserved() (bool, error)
+
+	// Subsystem returns the name of the kernel subsystem to which the GPIO's
+	// sysfs directory belongs, which is "gpio" for any valid GPIO. This can
+	// be used to sanity-check a system's sysfs layout.
+	//
+	// The GPIO must be exported.
+	Subsystem() (string, error)
+
 	// Open the corresponding GPIO so that it can be controlled by the
-	// caller.
-	Open() (pin Pin, err error)
+	// caller. Any given options customize the behavior of the returned pin.
+	Open(options ...PinOption) (pin Pin, err error)
+
+	// OpenWith opens the corresponding GPIO like Open, and then applies the
+	// configuration described by the given options before returning it. If
+	// any part of that configuration fails, the pin is closed again and an
+	// error is returned, so the caller never sees a partially-configured
+	// pin.
+	OpenWith(opts OpenOptions) (pin Pin, err error)
+
+	// OpenWithContext is like Open, but stops waiting and returns ctx.Err()
+	// if the given context is cancelled before the pin has been opened.
+	//
+	// The system calls involved in opening a pin cannot be interrupted, so
+	// in that case they continue on a separate goroutine and any resources
+	// they eventually obtain are then released. This prevents a misbehaving
+	// driver from blocking, for example, the shutdown of a program that is
+	// opening many GPIOs during discovery.
+	OpenWithContext(ctx context.Context, options ...PinOption) (pin Pin, err error)
 }
 
 // GpioChip represents an instance of a Linux GPIO driver that implements
@@ -97,12 +364,39 @@ type Node interface {
 // hardware documentation for the host system, but this interface provides
 // a way to implement generic linux GPIO control utilities.
 //
-// The API to obtain instances of this interface are not yet implemented.
+// Use ListGpioChips to find all of the chips on the system, or MakeGpioChip
+// to access a specific chip.
 type GpioChip interface {
-	FirstGpioNumber() (int, err error)
-	GpioCount() (int, err error)
-	LastGpioNumber() (int, err error)
-	Label() (string, err error)
+	// FirstGpioNumber returns the number of the first GPIO provided by
+	// this chip.
+	FirstGpioNumber() (int, error)
+
+	// GpioCount returns the number of GPIOs provided by this chip.
+	GpioCount() (int, error)
+
+	// LastGpioNumber returns the number of the last GPIO provided by this
+	// chip.
+	LastGpioNumber() (int, error)
+
+	// Label returns the label assigned to this chip by its kernel driver.
+	Label() (string, error)
+
+	// ExportRange exports count consecutive GPIOs of this chip, starting at
+	// the given offset from its first GPIO, and returns a Node for each.
+	//
+	// The GPIOs are exported in order. If exporting one fails, no more are
+	// attempted, and the nodes for those already exported are returned
+	// along with a *BulkError.
+	ExportRange(startOffset, count int) ([]Node, error)
+
+	// Stats returns counts of the exports, unexports and opens of this
+	// chip's GPIOs performed by this process, through any Node, since it
+	// started or since ResetStats was last called. Returns zero counts if
+	// the chip's range of GPIO numbers cannot be read.
+	Stats() ChipStats
+
+	// ResetStats sets the counts returned by Stats back to zero.
+	ResetStats()
 }
 
 type gpioNode struct {
@@ -111,17 +405,38 @@ type gpioNode struct {
 }
 
 type gpioPin struct {
-	node *gpioNode
-	dir  *os.File
-
-	// we pre-allocate some storage to avoid creating garbage each time we
-	// read a value (which will happen often in many programs) we pre-allocate
-	// an array and always read into it. Note however that this means that
-	// reading a value is not thread-safe. Worth fixing that?
-	readBuf     []byte
+	node    *gpioNode
+	dir     *os.File
+	options pinOptions
+
+	// mu guards access to the value file and to lastValue. Reads take only
+	// a read lock, since each reads into its own buffer using ReadAt and
+	// so doesn't disturb the file offset.
+	mu          sync.RWMutex
 	valueFile   *os.File
 	epollFd     int
 	epollEvents [1]syscall.EpollEvent
+
+	// edgeConfigured records whether edge sensitivity has been set to
+	// something other than gpio.NoEdges, either via this pin or, as found
+	// when the pin was opened, by some other means, since otherwise
+	// WaitForEdge would block forever.
+	edgeConfigured bool
+
+	// lastValue is the value most recently written via this pin, used to
+	// skip redundant writes when WithValueCoalescing is in effect. It is
+	// valid only if lastValueKnown is true.
+	lastValue      gpio.Value
+	lastValueKnown bool
+
+	// unexportOnClose causes Close to also unexport the GPIO, as requested
+	// by OpenOptions.UnexportOnClose.
+	unexportOnClose bool
+
+	// closeOnce ensures that only the first call to Close releases the
+	// pin's resources, since by the time of a second call the descriptor
+	// numbers may already have been reused for other files.
+	closeOnce sync.Once
 }
 
 // MakeNode is the primary way to get hold of a Node object
@@ -134,23 +449,46 @@ func MakeNode(number int) (node Node) {
 	return &gpioNode{number: number, path: path}
 }
 
-func (node *gpioNode) Exported() (result bool) {
-	_, err := os.Stat(node.path)
-	return err == nil
-}
+var exportedDirPattern = regexp.MustCompile(`^gpio[0-9]+$`)
 
-func (node *gpioNode) Export() (err error) {
-	file, err := os.OpenFile("/sys/class/gpio/export", os.O_WRONLY, 0)
+// ListExportedGPIOs returns a Node for each GPIO that is currently exported,
+// whether by this process or any other.
+func ListExportedGPIOs() ([]Node, error) {
+	entries, err := os.ReadDir("/sys/class/gpio")
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	_, err = file.WriteString(strconv.Itoa(node.number))
-	if err != nil {
-		return
+	var nodes []Node
+	for _, entry := range entries {
+		name := entry.Name()
+		if !exportedDirPattern.MatchString(name) {
+			continue
+		}
+
+		number, err := strconv.Atoi(name[len("gpio"):])
+		if err != nil {
+			// should never happen, given the pattern above
+			return nil, err
+		}
+		nodes = append(nodes, MakeNode(number))
 	}
+	return nodes, nil
+}
 
-	return nil
+func (node *gpioNode) Exported() (result bool) {
+	_, err := os.Stat(node.path)
+	return err == nil
+}
+
+func (node *gpioNode) Export() (err error) {
+	err = writeControlFile("/sys/class/gpio/export", node.number)
+	err = gpioError("exporting", node.number, err, map[syscall.Errno]error{
+		syscall.EBUSY:  ErrAlreadyExported,
+		syscall.EINVAL: ErrInvalidPinNumber,
+	})
+	countOp(node.number, exportsField, err)
+	return err
 }
 
 func (node *gpioNode) ExportIfNecessary() (exported bool, err error) {
@@ -167,39 +505,318 @@ func (node *gpioNode) ExportIfNecessary() (exported bool, err error) {
 }
 
 func (node *gpioNode) Unexport() (err error) {
-	file, err := os.OpenFile("/sys/class/gpio/unexport", os.O_WRONLY, 0)
+	err = writeControlFile("/sys/class/gpio/unexport", node.number)
+	err = gpioError("unexporting", node.number, err, map[syscall.Errno]error{
+		syscall.EINVAL: ErrNotExported,
+	})
+	countOp(node.number, unexportsField, err)
+	return err
+}
+
+// writeControlFile writes a GPIO number to one of the control files in
+// /sys/class/gpio, such as "export".
+func writeControlFile(path string, number int) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
 	if err != nil {
-		return
+		return err
 	}
+	defer file.Close()
+
+	_, err = file.WriteString(strconv.Itoa(number))
+	return err
+}
 
-	_, err = file.WriteString(strconv.Itoa(node.number))
+func (node *gpioNode) WaitForUnexport(ctx context.Context) error {
+	return waitForDirChange(ctx, "/sys/class/gpio", syscall.IN_DELETE, func() bool {
+		return !node.Exported()
+	})
+}
+
+func (node *gpioNode) WaitForExport(ctx context.Context) error {
+	// inotify doesn't report directories created by the kernel in sysfs,
+	// so we must poll. Most waits are very short, so we start by checking
+	// frequently and then back off.
+	delay := time.Millisecond
+	for !node.Exported() {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		delay *= 2
+		if delay > 100*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+	}
+	return nil
+}
+
+// pinFilesTimeout is how long opening a pin will wait for its value file to
+// appear if it doesn't exist yet.
+const pinFilesTimeout = time.Second
+
+// WaitForPinFiles blocks until the given GPIO is exported and its "value"
+// and "direction" attribute files exist, or until the given context is
+// cancelled.
+//
+// The kernel creates a GPIO's attribute files asynchronously after it is
+// exported, so a program that exports a GPIO and then immediately opens it
+// may otherwise find them missing. Node.Open already waits briefly for
+// the value file itself, so this is needed only by programs that access
+// the attribute files in some other way.
+//
+// Some drivers don't allow the direction of certain GPIOs to be changed,
+// and the kernel then doesn't create a "direction" file for them, in which
+// case this waits until the context is cancelled.
+func WaitForPinFiles(node Node, ctx context.Context) error {
+	err := node.WaitForExport(ctx)
 	if err != nil {
-		return
+		return err
 	}
 
-	return nil
+	path := fmt.Sprintf("/sys/class/gpio/gpio%d", node.Number())
+	return waitForDirChange(ctx, path, syscall.IN_CREATE, func() bool {
+		for _, name := range []string{"value", "direction"} {
+			_, err := os.Stat(filepath.Join(path, name))
+			if err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (node *gpioNode) MapAttributes() (map[string]string, error) {
+	entries, err := os.ReadDir(node.path)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string)
+	for _, entry := range entries {
+		// Attributes are regular files. Skip symlinks to other devices and
+		// subdirectories such as "power".
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(node.path, entry.Name()))
+		if err != nil {
+			continue
+		}
+		attrs[entry.Name()] = strings.TrimSpace(string(data))
+	}
+	return attrs, nil
+}
+
+// ReservedDrivers lists the names of the kernel drivers whose GPIO
+// controllers are reported as reserved by Node.IsSystemReserved. Names must
+// match exactly, as shown by the "driver" symlink of the controller's
+// device in sysfs. Applications may change it to suit their hardware, but
+// not concurrently with calls to IsSystemReserved.
+var ReservedDrivers = []string{"pinctrl", "regulator"}
+
+func (node *gpioNode) IsSystemReserved() (bool, error) {
+	target, err := os.Readlink(filepath.Join(node.path, "device", "driver"))
+	if os.IsNotExist(err) {
+		// A device with no driver can't have been reserved by one.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	driver := filepath.Base(target)
+	for _, reserved := range ReservedDrivers {
+		if driver == reserved {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (node *gpioNode) Subsystem() (string, error) {
+	target, err := os.Readlink(filepath.Join(node.path, "subsystem"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
 }
 
 func (node *gpioNode) Number() int {
 	return node.number
 }
 
-func (node *gpioNode) Open() (Pin, error) {
-	dir, err := os.Open(node.path)
+func (node *gpioNode) Open(options ...PinOption) (Pin, error) {
+	return node.OpenWithContext(context.Background(), options...)
+}
+
+func (node *gpioNode) OpenWith(opts OpenOptions) (Pin, error) {
+	if opts.ConsumerLabel != "" {
+		return nil, fmt.Errorf("%w: cannot set consumer label for GPIO %d", ErrUnsupported, node.number)
+	}
+
+	exported := false
+	if opts.AutoExport {
+		var err error
+		exported, err = node.ExportIfNecessary()
+		if err != nil && !errors.Is(err, ErrAlreadyExported) {
+			return nil, err
+		}
+	}
+
+	pin, err := node.open(pinOptions{})
+	if err == nil {
+		err = pin.configure(opts)
+		if err != nil {
+			pin.Close()
+		}
+	}
 	if err != nil {
+		if exported {
+			// Leave the GPIO as we found it.
+			node.Unexport()
+		}
 		return nil, err
 	}
+
+	// If we were asked to export the GPIO only if necessary then we also
+	// unexport it only if we exported it.
+	pin.unexportOnClose = opts.UnexportOnClose && (exported || !opts.AutoExport)
+	return pin, nil
+}
+
+// configure applies the settings described by the given options to a newly
+// opened pin.
+func (pin *gpioPin) configure(opts OpenOptions) error {
+	// We set active_low first so that once the GPIO is an output its
+	// logical value is never briefly inverted. OutLow and OutHigh are
+	// physical levels, though, which the kernel applies regardless of it.
+	if opts.ActiveLow {
+		err := pin.SetActiveLow(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Direction != gpio.In {
+		err := pin.SetDirection(opts.Direction)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.EdgeSensitivity != gpio.NoEdges {
+		err := pin.SetupEdgeWaiting(opts.EdgeSensitivity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (node *gpioNode) OpenWithContext(ctx context.Context, options ...PinOption) (Pin, error) {
+	var opts pinOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.openTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, opts.openTimeout)
+		defer cancel()
+
+		pin, err := node.openContext(timeoutCtx, opts)
+		if err == context.DeadlineExceeded && ctx.Err() == nil {
+			return nil, ErrOpenTimeout
+		}
+		return pin, err
+	}
+
+	return node.openContext(ctx, opts)
+}
+
+// openContext runs open in a separate goroutine so that we can stop waiting
+// for it if the given context is cancelled. In that case the goroutine is
+// abandoned, and closes the pin itself if it eventually succeeds.
+func (node *gpioNode) openContext(ctx context.Context, opts pinOptions) (Pin, error) {
+	if ctx.Done() == nil {
+		// Context can never be cancelled, so we can just block.
+		pin, err := node.open(opts)
+		if err != nil {
+			return nil, err
+		}
+		return pin, nil
+	}
+
+	type result struct {
+		pin *gpioPin
+		err error
+	}
+	results := make(chan result, 1)
+	abandoned := make(chan struct{})
+
+	go func() {
+		pin, err := node.open(opts)
+		select {
+		case results <- result{pin, err}:
+		case <-abandoned:
+			if err == nil {
+				pin.Close()
+			}
+		}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.pin, nil
+	case <-ctx.Done():
+		close(abandoned)
+		return nil, ctx.Err()
+	}
+}
+
+func (node *gpioNode) open(opts pinOptions) (*gpioPin, error) {
+	pin, err := node.openPin(opts)
+	countOp(node.number, opensField, err)
+	return pin, err
+}
+
+// openPin does the work of open, which wraps it to count the outcome for
+// GpioChip.Stats.
+func (node *gpioNode) openPin(opts pinOptions) (*gpioPin, error) {
+	dir, err := os.Open(node.path)
+	if err != nil {
+		return nil, gpioError("opening", node.number, err, map[syscall.Errno]error{
+			syscall.ENOENT: ErrNotExported,
+		})
+	}
 	defer func() {
 		if err != nil {
 			dir.Close()
 		}
 	}()
 
-	readBuf := make([]byte, 1, 1)
-	pin := &gpioPin{node: node, dir: dir, readBuf: readBuf}
+	pin := &gpioPin{node: node, dir: dir, options: opts}
 
 	pin.valueFile, err = pin.openFile("value")
+	if errors.Is(err, syscall.ENOENT) {
+		// The GPIO may have only just been exported. If the files still
+		// haven't appeared after waiting, we try once more anyway in case
+		// it's only the direction file that is missing.
+		ctx, cancel := context.WithTimeout(context.Background(), pinFilesTimeout)
+		WaitForPinFiles(node, ctx)
+		cancel()
+		pin.valueFile, err = pin.openFile("value")
+	}
 	if err != nil {
+		err = gpioError("opening", node.number, err, nil)
 		return nil, err
 	}
 	defer func() {
@@ -229,10 +846,34 @@ func (node *gpioNode) Open() (Pin, error) {
 		return nil, err
 	}
 
+	// The edge sensitivity may already have been set by another program or
+	// by an earlier pin. GPIOs that can't generate interrupts have no edge
+	// attribute at all, and so can never have it set.
+	edge, edgeErr := pin.readFile("edge")
+	pin.edgeConfigured = edgeErr == nil && edge != "none"
+
+	if opts.outputMode != "" {
+		err = pin.writeFile("direction", opts.outputMode+"\n")
+		if errors.Is(err, syscall.EINVAL) {
+			err = fmt.Errorf("%w: kernel does not accept direction %q for GPIO %d", ErrUnsupported, opts.outputMode, node.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return pin, nil
 }
 
 func (pin *gpioPin) Close() (err error) {
+	pin.closeOnce.Do(func() {
+		err = pin.close()
+	})
+	return err
+}
+
+// close is the implementation of Close, which must be called only once.
+func (pin *gpioPin) close() error {
 	// Try to close whatever we can before checking for errors
 	// so that we'll have closed as much as possible before we
 	// return. Unfortunately this means the caller won't get the
@@ -240,12 +881,22 @@ func (pin *gpioPin) Close() (err error) {
 	// and edge case and not worth worrying too much about.
 	dirCloseErr := pin.dir.Close()
 	fileCloseErr := pin.valueFile.Close()
+	epollCloseErr := syscall.Close(pin.epollFd)
+
+	var unexportErr error
+	if pin.unexportOnClose {
+		unexportErr = pin.node.Unexport()
+	}
 
 	switch {
+	case unexportErr != nil:
+		return unexportErr
 	case dirCloseErr != nil:
 		return dirCloseErr
 	case fileCloseErr != nil:
 		return fileCloseErr
+	case epollCloseErr != nil:
+		return epollCloseErr
 	default:
 		return nil
 	}
@@ -255,10 +906,32 @@ func (pin *gpioPin) Node() Node {
 	return pin.node
 }
 
+// CloseAndUnexport closes the given pin and then asks the kernel to unexport
+// its GPIO, which is the usual cleanup sequence for a program that exported
+// a GPIO for its own use:
+//
+//	pin, err := node.Open()
+//	if err != nil {
+//	    return err
+//	}
+//	defer linuxgpio.CloseAndUnexport(pin)
+//
+// The unexport is attempted even if closing the pin fails. If either step
+// fails, the returned error combines all of the errors that occurred.
+func CloseAndUnexport(pin Pin) error {
+	closeErr := pin.Close()
+	unexportErr := pin.Node().Unexport()
+	return errors.Join(closeErr, unexportErr)
+}
+
 func (pin *gpioPin) Number() int {
 	return pin.node.number
 }
 
+func (pin *gpioPin) Dir() *os.File {
+	return pin.dir
+}
+
 func (pin *gpioPin) openFile(name string) (*os.File, error) {
 	fd, err := syscall.Openat(int(pin.dir.Fd()), name, os.O_RDWR, 0)
 	if err != nil {
@@ -277,59 +950,324 @@ func (pin *gpioPin) writeFile(name string, value string) error {
 		return err
 	}
 
+	defer file.Close()
+
 	_, err = file.WriteString(value)
 	return err
 }
 
+// readFile returns the content of the given sysfs attribute with any
+// trailing newline removed.
+func (pin *gpioPin) readFile(name string) (string, error) {
+	file, err := pin.openFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// AttributeValueError is returned when a sysfs attribute of a GPIO contains
+// a value that this package doesn't understand.
+type AttributeValueError struct {
+	// Attribute is the name of the attribute, such as "direction".
+	Attribute string
+
+	// Value is the value that was read, with surrounding whitespace removed.
+	Value string
+}
+
+func (err *AttributeValueError) Error() string {
+	return fmt.Sprintf("unexpected value %q in GPIO attribute %q", err.Value, err.Attribute)
+}
+
+func (pin *gpioPin) Reset() error {
+	err := pin.SetSensitivity(gpio.NoEdges)
+	if err != nil {
+		return err
+	}
+
+	dir, err := pin.readFile("direction")
+	if err != nil {
+		return err
+	}
+	if dir == "out" {
+		err = pin.SetValue(gpio.Low)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = pin.SetDirection(gpio.In)
+	if err != nil {
+		return err
+	}
+
+	return pin.SetActiveLow(false)
+}
+
 func (pin *gpioPin) SetDirection(dir gpio.Direction) error {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+	return pin.setDirection(dir)
+}
+
+// setDirection is the implementation of SetDirection, for use by callers
+// that already hold the write lock.
+func (pin *gpioPin) setDirection(dir gpio.Direction) error {
+	var err error
 	switch dir {
 	case gpio.In:
-		return pin.writeFile("direction", "in\n")
+		err = pin.writeFile("direction", "in\n")
 	case gpio.Out:
-		return pin.writeFile("direction", "out\n")
+		err = pin.writeFile("direction", "out\n")
+	case OutLow:
+		err = pin.writeFile("direction", "low\n")
+	case OutHigh:
+		err = pin.writeFile("direction", "high\n")
 	default:
 		// should never happen in a valid program
 		panic("Invalid gpio.Direction value")
 	}
+	if err != nil {
+		pin.lastValueKnown = false
+		return err
+	}
+
+	pin.lastValueKnown = false
+	if pin.options.valueCoalescing && (dir == OutLow || dir == OutHigh) {
+		// The kernel drives the line to the given physical level regardless
+		// of active_low, so the logical value we've set depends on it.
+		activeLow, err := pin.ReadActiveLow()
+		if err == nil {
+			pin.lastValue, pin.lastValueKnown = gpio.Low, true
+			if (dir == OutHigh) != activeLow {
+				pin.lastValue = gpio.High
+			}
+		}
+	}
+	return nil
+}
+
+func (pin *gpioPin) SetDirectionOutput(initialValue gpio.Value) error {
+	if initialValue != gpio.Low && initialValue != gpio.High {
+		// should never happen in a valid program
+		panic("Invalid gpio.Value value")
+	}
+
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	// The kernel takes "low" and "high" as physical levels, so we must
+	// invert the value ourselves for an active-low GPIO.
+	activeLow, err := pin.ReadActiveLow()
+	if err != nil {
+		return err
+	}
+	if (initialValue == gpio.High) != activeLow {
+		return pin.setDirection(OutHigh)
+	}
+	return pin.setDirection(OutLow)
+}
+
+func (pin *gpioPin) ReadDirection() (gpio.Direction, error) {
+	value, err := pin.readFile("direction")
+	if err != nil {
+		return 0, err
+	}
+
+	switch value {
+	case "in":
+		return gpio.In, nil
+	case "out":
+		return gpio.Out, nil
+	default:
+		return 0, &AttributeValueError{Attribute: "direction", Value: value}
+	}
+}
+
+func (pin *gpioPin) SetActiveLow(invert bool) error {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	// Changing the polarity changes the logical value of an output without
+	// changing the line itself, so we no longer know its value.
+	pin.lastValueKnown = false
+
+	if invert {
+		return pin.writeFile("active_low", "1\n")
+	}
+	return pin.writeFile("active_low", "0\n")
+}
+
+func (pin *gpioPin) ReadActiveLow() (bool, error) {
+	value, err := pin.readFile("active_low")
+	if err != nil {
+		return false, err
+	}
+
+	switch value {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, &AttributeValueError{Attribute: "active_low", Value: value}
+	}
 }
 
 func (pin *gpioPin) SetSensitivity(dir gpio.EdgeSensitivity) error {
+	var err error
 	switch dir {
 	case gpio.NoEdges:
-		return pin.writeFile("edge", "none\n")
+		err = pin.writeFile("edge", "none\n")
 	case gpio.RisingEdge:
-		return pin.writeFile("edge", "rising\n")
+		err = pin.writeFile("edge", "rising\n")
 	case gpio.FallingEdge:
-		return pin.writeFile("edge", "falling\n")
+		err = pin.writeFile("edge", "falling\n")
 	case gpio.BothEdges:
-		return pin.writeFile("edge", "both\n")
+		err = pin.writeFile("edge", "both\n")
 	default:
 		// should never happen in a valid program
 		panic("Invalid gpio.EdgeSensitivity value")
 	}
+	if err != nil {
+		return err
+	}
+
+	pin.edgeConfigured = dir != gpio.NoEdges
+	return nil
+}
+
+func (pin *gpioPin) ReadEdgeSensitivity() (gpio.EdgeSensitivity, error) {
+	value, err := pin.readFile("edge")
+	if err != nil {
+		return 0, err
+	}
+
+	switch value {
+	case "none":
+		return gpio.NoEdges, nil
+	case "rising":
+		return gpio.RisingEdge, nil
+	case "falling":
+		return gpio.FallingEdge, nil
+	case "both":
+		return gpio.BothEdges, nil
+	default:
+		return 0, &AttributeValueError{Attribute: "edge", Value: value}
+	}
+}
+
+func (pin *gpioPin) SetupEdgeWaiting(s gpio.EdgeSensitivity) error {
+	// The value file is always registered with our epoll instance when
+	// the pin is opened, so setting the sensitivity is all that remains.
+	return pin.SetSensitivity(s)
 }
 
 func (pin *gpioPin) WaitForEdge() error {
-	_, err := syscall.EpollWait(pin.epollFd, pin.epollEvents[:], -1)
-	return err
+	if !pin.edgeConfigured {
+		return ErrEdgeNotConfigured
+	}
+
+	for {
+		_, err := syscall.EpollWait(pin.epollFd, pin.epollEvents[:], -1)
+		if err == syscall.EINTR {
+			// Interrupted by a signal, which happens routinely in Go
+			// programs because the runtime uses signals for preemption.
+			continue
+		}
+		return err
+	}
 }
 
 func (pin *gpioPin) SetValue(value gpio.Value) error {
-	var err error = nil
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+	return pin.setValue(value)
+}
+
+func (pin *gpioPin) Toggle() error {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+
+	value, err := pin.value()
+	if err != nil {
+		return err
+	}
+	if value == gpio.High {
+		return pin.setValue(gpio.Low)
+	}
+	return pin.setValue(gpio.High)
+}
+
+// setValue is the implementation of SetValue, for use by callers that
+// already hold the write lock.
+func (pin *gpioPin) setValue(value gpio.Value) error {
+	if pin.options.valueCoalescing && pin.lastValueKnown && value == pin.lastValue {
+		return nil
+	}
+
+	var data []byte
 	switch value {
 	case gpio.High:
-		_, err = pin.valueFile.WriteAt(highData, 0)
+		data = highData
 	case gpio.Low:
-		_, err = pin.valueFile.WriteAt(lowData, 0)
+		data = lowData
 	default:
 		// should never happen in a valid program
 		panic("Invalid gpio.Value value")
 	}
+
+	err := pin.writeValue(data)
+	backoff := 100 * time.Microsecond
+	for retry := 0; retry < pin.options.eagainRetries && errors.Is(err, syscall.EAGAIN); retry++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = pin.writeValue(data)
+	}
+	if err != nil {
+		pin.lastValueKnown = false
+		return err
+	}
+
+	pin.lastValue, pin.lastValueKnown = value, true
+	return nil
+}
+
+func (pin *gpioPin) writeValue(data []byte) error {
+	if pin.options.seekAndWrite {
+		_, err := pin.valueFile.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = pin.valueFile.Write(data)
+		return err
+	}
+
+	_, err := pin.valueFile.WriteAt(data, 0)
 	return err
 }
 
 func (pin *gpioPin) Value() (gpio.Value, error) {
-	bytes, err := pin.valueFile.ReadAt(pin.readBuf, 0)
+	pin.mu.RLock()
+	defer pin.mu.RUnlock()
+	return pin.value()
+}
+
+// value is the implementation of Value, for use by callers that already
+// hold the lock.
+func (pin *gpioPin) value() (gpio.Value, error) {
+	// A local buffer rather than one shared by the whole pin allows
+	// concurrent reads, and doesn't escape so causes no allocation.
+	var buf [1]byte
+	bytes, err := pin.valueFile.ReadAt(buf[:], 0)
 	if err != nil {
 		return 0, err
 	}
@@ -338,11 +1276,11 @@ func (pin *gpioPin) Value() (gpio.Value, error) {
 		panic("Kernel returned nothing from 'value'")
 	}
 
-	switch pin.readBuf[0] {
+	switch buf[0] {
 	case '0':
-		return gpio.High, nil
-	case '1':
 		return gpio.Low, nil
+	case '1':
+		return gpio.High, nil
 	default:
 		// should never happen
 		panic("Kernel returned invalid data from 'value'")
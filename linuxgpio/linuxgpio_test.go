@@ -0,0 +1,58 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"github.com/apparentlymart/go-gpio/gpio"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mockPin returns a pin whose sysfs directory is a temporary directory
+// containing a value file with the given content.
+func mockPin(t *testing.T, value string) *gpioPin {
+	t.Helper()
+
+	path := t.TempDir()
+	err := os.WriteFile(filepath.Join(path, "value"), []byte(value), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dir.Close() })
+
+	pin := &gpioPin{node: &gpioNode{path: path}, dir: dir}
+	pin.valueFile, err = pin.openFile("value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pin.valueFile.Close() })
+
+	return pin
+}
+
+func TestPinValue(t *testing.T) {
+	tests := []struct {
+		content string
+		want    gpio.Value
+	}{
+		{"0\n", gpio.Low},
+		{"1\n", gpio.High},
+	}
+
+	for _, test := range tests {
+		pin := mockPin(t, test.content)
+		got, err := pin.Value()
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", test.content, err)
+		}
+		if got != test.want {
+			t.Errorf("wrong value for %q\ngot:  %v\nwant: %v", test.content, got, test.want)
+		}
+	}
+}
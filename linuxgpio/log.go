@@ -0,0 +1,65 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"io"
+	"syscall"
+	"time"
+)
+
+// LogValues reads the value of the given pin at the given interval and
+// writes each reading to w as a line of the form "<timestamp>,<value>",
+// where the timestamp is in RFC 3339 format with nanoseconds and the value
+// is 0 or 1. It continues until the given context is cancelled, and then
+// returns ctx.Err(), or until reading or writing fails.
+//
+// If w has a Flush or Sync method, such as a *bufio.Writer or an *os.File,
+// it is called after each line so that as little data as possible is lost
+// if the program crashes. Files that don't support syncing, such as pipes
+// and terminals, are not synced.
+func LogValues(pin Pin, interval time.Duration, w io.Writer, ctx context.Context) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		value, err := pin.Value()
+		if err != nil {
+			return err
+		}
+
+		digit := 0
+		if value == gpio.High {
+			digit = 1
+		}
+		_, err = fmt.Fprintf(w, "%s,%d\n", time.Now().Format(time.RFC3339Nano), digit)
+		if err != nil {
+			return err
+		}
+
+		switch w := w.(type) {
+		case interface{ Flush() error }:
+			err = w.Flush()
+		case interface{ Sync() error }:
+			err = w.Sync()
+			if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) {
+				// Pipes, terminals and the like can't be synced, but
+				// there's nothing to lose by not syncing them anyway.
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// NotifyMQ sets the given pin's edge sensitivity and then sends a message
+// to the named POSIX message queue each time an edge is detected, allowing
+// other processes to receive GPIO events using mq_receive.
+//
+// The queue is created with default attributes and permissions 0600 if it
+// doesn't already exist. The name may be given with or without the leading
+// slash required by mq_open(3). Each message is a line of text containing the
+// GPIO number and the value read just after the edge, such as "17 1\n".
+//
+// NotifyMQ does not return unless an error occurs, so it will usually be
+// called in its own goroutine. If the queue is full, it blocks until there
+// is space, and any edges that occur meanwhile are reported as at most one
+// further message.
+func NotifyMQ(pin Pin, mqName string, sensitivity gpio.EdgeSensitivity) error {
+	mqd, err := mqOpen(mqName)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(mqd)
+
+	err = pin.SetupEdgeWaiting(sensitivity)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err := pin.WaitForEdge()
+		if err != nil {
+			return err
+		}
+
+		value, err := pin.Value()
+		if err != nil {
+			return err
+		}
+		bit := 0
+		if value == gpio.High {
+			bit = 1
+		}
+
+		err = mqSend(mqd, []byte(fmt.Sprintf("%d %d\n", pin.Number(), bit)))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// mqOpen opens the named message queue for writing, creating it if needed.
+// The kernel's mq_open system call expects the name without the leading
+// slash that the C library function requires.
+func mqOpen(name string) (int, error) {
+	namePtr, err := syscall.BytePtrFromString(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return -1, err
+	}
+
+	mqd, _, errno := syscall.Syscall6(
+		syscall.SYS_MQ_OPEN,
+		uintptr(unsafe.Pointer(namePtr)),
+		uintptr(syscall.O_WRONLY|syscall.O_CREAT|syscall.O_CLOEXEC),
+		0600,
+		0, // default queue attributes
+		0, 0,
+	)
+	if errno != 0 {
+		return -1, fmt.Errorf("mq_open %s: %w", name, errno)
+	}
+	return int(mqd), nil
+}
+
+func mqSend(mqd int, msg []byte) error {
+	for {
+		_, _, errno := syscall.Syscall6(
+			syscall.SYS_MQ_TIMEDSEND,
+			uintptr(mqd),
+			uintptr(unsafe.Pointer(&msg[0])),
+			uintptr(len(msg)),
+			0, // priority
+			0, // no timeout
+			0,
+		)
+		switch errno {
+		case 0:
+			return nil
+		case syscall.EINTR:
+			continue
+		default:
+			return errno
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrMuxNotSupported is returned by SetMux and GetMux when the kernel does
+// not expose pin multiplexing controls for the selected GPIO.
+var ErrMuxNotSupported = errors.New("pin multiplexing is not supported for this GPIO")
+
+// MuxConfig describes the multiplexing configuration of a physical pin on a
+// SoC where each pin can act either as a GPIO or as some alternate function,
+// such as a UART or SPI signal.
+//
+// The mainline sysfs GPIO interface does not expose multiplexing at all, but
+// some vendor kernels add a "mux" attribute alongside "direction" and
+// "value". That attribute contains the mode, optionally followed by the name
+// of the selected alternate function, separated by whitespace. The set of
+// valid modes and function names is specific to the SoC and kernel.
+type MuxConfig struct {
+	PinNumber         int
+	Mode              string
+	AlternateFunction string
+}
+
+// SetMux writes the given multiplexing configuration to the "mux" attribute
+// of the GPIO given in cfg.PinNumber, which must already be exported.
+//
+// Returns ErrMuxNotSupported if the kernel does not provide a "mux" attribute
+// for the GPIO.
+func SetMux(cfg MuxConfig) error {
+	path, err := muxPath(cfg.PinNumber)
+	if err != nil {
+		return err
+	}
+
+	value := cfg.Mode
+	if cfg.AlternateFunction != "" {
+		value = value + " " + cfg.AlternateFunction
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(value + "\n")
+	return err
+}
+
+// GetMux reads the current multiplexing configuration of the given GPIO,
+// which must already be exported.
+//
+// Returns ErrMuxNotSupported if the kernel does not provide a "mux" attribute
+// for the GPIO.
+func GetMux(number int) (MuxConfig, error) {
+	cfg := MuxConfig{PinNumber: number}
+
+	path, err := muxPath(number)
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return cfg, fmt.Errorf("kernel returned empty mux setting for GPIO %d", number)
+	}
+	cfg.Mode = fields[0]
+	if len(fields) > 1 {
+		cfg.AlternateFunction = strings.Join(fields[1:], " ")
+	}
+
+	return cfg, nil
+}
+
+// muxPath returns the path of the "mux" attribute for the given GPIO,
+// distinguishing between a GPIO that isn't exported (which produces the
+// error from trying to find its directory) and one whose kernel driver
+// doesn't support multiplexing (which produces ErrMuxNotSupported).
+func muxPath(number int) (string, error) {
+	node := MakeNode(number).(*gpioNode)
+
+	_, err := os.Stat(node.path)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(node.path, "mux")
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", ErrMuxNotSupported
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
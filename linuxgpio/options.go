@@ -0,0 +1,200 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+// ErrOpenTimeout is returned by Node.Open when the WithOpenTimeout option is
+// used and opening the pin takes longer than the given duration.
+var ErrOpenTimeout = errors.New("timed out opening GPIO")
+
+// ErrUnsupported is returned when an option requests a feature that the
+// kernel's sysfs GPIO interface does not support.
+var ErrUnsupported = errors.New("not supported by the sysfs GPIO interface")
+
+// PinOption customizes the behavior of a Pin returned by Node.Open.
+type PinOption func(*pinOptions)
+
+type pinOptions struct {
+	seekAndWrite bool
+	openTimeout  time.Duration
+
+	valueCoalescing bool
+	eagainRetries   int
+
+	goroutineSched    SchedPolicy
+	goroutineSchedSet bool
+
+	goroutineAffinity    int
+	goroutineAffinitySet bool
+
+	// outputMode, if set, is written to the "direction" attribute when the
+	// pin is opened.
+	outputMode string
+}
+
+// WithSeekAndWrite makes SetValue write to the sysfs "value" file by seeking
+// to its start and then writing, using the lseek and write system calls,
+// rather than using a single positioned write with pwrite.
+//
+// This is a workaround for some embedded kernels where pwrite on sysfs
+// attributes has been observed to take considerably longer than a separate
+// seek and write. It costs an extra system call per write, so it should be
+// used only where the problem has been confirmed by measurement on the
+// target system.
+func WithSeekAndWrite() PinOption {
+	return func(options *pinOptions) {
+		options.seekAndWrite = true
+	}
+}
+
+// WithOpenTimeout limits how long Node.Open may take to open the pin, after
+// which it returns ErrOpenTimeout.
+//
+// Opening a pin normally completes almost immediately, but a misbehaving
+// kernel driver can cause the underlying system calls to block
+// indefinitely. In that case the blocked system calls cannot be
+// interrupted, so they continue on a separate goroutine after Open returns
+// and any resources they eventually obtain are then released.
+func WithOpenTimeout(d time.Duration) PinOption {
+	return func(options *pinOptions) {
+		options.openTimeout = d
+	}
+}
+
+// WithValueCoalescing makes SetValue skip writing to sysfs when the value
+// being set is the same as the one most recently set via the same pin,
+// saving a system call for applications that repeatedly set the same value.
+//
+// The pin only remembers values that it set itself, so this must not be
+// used if anything else, such as another process or another Pin opened for
+// the same GPIO, might also change the value. It also must not be used if
+// the application relies on every write reaching the hardware.
+func WithValueCoalescing() PinOption {
+	return func(options *pinOptions) {
+		options.valueCoalescing = true
+	}
+}
+
+// WithEagainRetry makes SetValue retry a write to sysfs that fails with
+// EAGAIN, up to the given number of times. The first retry happens after
+// 100 microseconds, and each subsequent retry waits twice as long as the
+// one before.
+//
+// Writes to a GPIO value normally never fail in this way, but some drivers
+// on certain ARM SoCs have been observed to return EAGAIN transiently while
+// the GPIO controller is busy.
+func WithEagainRetry(maxRetries int) PinOption {
+	return func(options *pinOptions) {
+		options.eagainRetries = maxRetries
+	}
+}
+
+// WithGoroutineScheduler sets the scheduling policy of the operating system
+// thread running the background goroutine started by the pin's WatchEdges
+// method. With SchedFIFO or SchedRR, the thread runs at real-time priority
+// 49, just below the default priority of the kernel's threaded interrupt
+// handlers, which reduces the delay between an edge and its delivery when
+// the system is busy.
+//
+// The goroutine is locked to its thread for its whole life, and the thread
+// is discarded when the goroutine exits. Real-time policies require the
+// CAP_SYS_NICE capability or a suitable RLIMIT_RTPRIO limit; otherwise
+// WatchEdges fails with EPERM.
+//
+// If the pin is wrapped using CoalesceEdges, the policy is instead applied
+// to the wrapper's background goroutine, which is the one that waits for
+// the underlying pin's edges, and any failure is returned by the wrapper's
+// edge-waiting methods.
+func WithGoroutineScheduler(policy SchedPolicy) PinOption {
+	return func(options *pinOptions) {
+		options.goroutineSched = policy
+		options.goroutineSchedSet = true
+	}
+}
+
+// WithGoroutineAffinity restricts the operating system thread running the
+// background goroutine started by the pin's WatchEdges method to the given
+// CPU, so that it doesn't migrate between CPUs. Combined with
+// SetInterruptAffinity, this allows both the GPIO interrupt and the
+// goroutine handling it to be kept on the same CPU, reducing cross-CPU cache
+// traffic.
+//
+// As with WithGoroutineScheduler, the goroutine is locked to its thread for
+// its whole life, and the option applies in the same way to pins wrapped
+// using CoalesceEdges. WatchEdges fails with EINVAL if the CPU doesn't exist
+// or is not available to this process.
+func WithGoroutineAffinity(cpu int) PinOption {
+	return func(options *pinOptions) {
+		options.goroutineAffinity = cpu
+		options.goroutineAffinitySet = true
+	}
+}
+
+// WithOpenDrain asks for the pin to be configured as an open-drain output
+// when it is opened, by writing "output-open-drain" to its "direction"
+// attribute. WithOpenSource similarly writes "output-open-source".
+//
+// The mainline kernel's sysfs interface does not accept these directions,
+// and supports open-drain and open-source outputs only via the GPIO
+// character device or device tree configuration, but some vendor kernels
+// do accept them. If the kernel rejects the direction, Node.Open fails with
+// an error wrapping ErrUnsupported.
+//
+// A later call to SetDirection on the pin replaces this configuration.
+func WithOpenDrain() PinOption {
+	return func(options *pinOptions) {
+		options.outputMode = "output-open-drain"
+	}
+}
+
+// WithOpenSource is like WithOpenDrain, but for an open-source output.
+func WithOpenSource() PinOption {
+	return func(options *pinOptions) {
+		options.outputMode = "output-open-source"
+	}
+}
+
+// OpenOptions describes the initial configuration of a pin opened with
+// Node.OpenWith.
+//
+// The zero value of each field leaves the corresponding setting as the
+// kernel currently has it, so for example a GPIO that another program left
+// configured as an output remains an output if Direction is gpio.In.
+type OpenOptions struct {
+	// Direction, if not gpio.In, is passed to SetDirection. This may be
+	// OutLow or OutHigh to avoid a glitch on the output. These are physical
+	// levels even if ActiveLow is set, so with ActiveLow, OutHigh gives a
+	// logical value of gpio.Low.
+	Direction gpio.Direction
+
+	// EdgeSensitivity, if not gpio.NoEdges, is passed to SetupEdgeWaiting.
+	EdgeSensitivity gpio.EdgeSensitivity
+
+	// ActiveLow, if set, inverts the meaning of the GPIO's values, by
+	// writing to its "active_low" attribute.
+	ActiveLow bool
+
+	// ConsumerLabel names the user of the GPIO, as shown in kernel debug
+	// output. The sysfs interface has no way to set it, so a non-empty
+	// label causes OpenWith to fail with an error wrapping ErrUnsupported.
+	ConsumerLabel string
+
+	// UnexportOnClose, if set, makes the pin's Close method also unexport
+	// the GPIO, after closing the pin's files. If unexporting fails, the
+	// files are still closed and the error from unexporting is returned.
+	//
+	// Unless AutoExport is also set, the GPIO is unexported even if it was
+	// exported by some other process, which may then fail if it is still
+	// using it.
+	UnexportOnClose bool
+
+	// AutoExport, if set, makes OpenWith export the GPIO first if it isn't
+	// already exported. If UnexportOnClose is also set then the GPIO is
+	// unexported on close only if it was exported by OpenWith.
+	AutoExport bool
+}
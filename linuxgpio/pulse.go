@@ -0,0 +1,78 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+func (pin *gpioPin) WaitForPulse(polarity gpio.Value, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return pin.measurePulse(ctx, polarity)
+}
+
+func (pin *gpioPin) MeasurePulseWidth(ctx context.Context, polarity gpio.Value) (time.Duration, error) {
+	return pin.measurePulse(ctx, polarity)
+}
+
+// measurePulse waits for the pin to change to the given value and then back
+// again, returning the time between the two changes.
+func (pin *gpioPin) measurePulse(ctx context.Context, polarity gpio.Value) (width time.Duration, err error) {
+	previous, err := pin.ReadEdgeSensitivity()
+	if err != nil {
+		return 0, err
+	}
+	err = pin.SetSensitivity(gpio.BothEdges)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		restoreErr := pin.SetSensitivity(previous)
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	// Discard any edges left over from before, which would otherwise make
+	// us start timing from some earlier change.
+	err = pin.FlushEdges()
+	if err != nil {
+		return 0, err
+	}
+
+	// If the pin already has the given value then we've missed the start of
+	// the current pulse, so we must wait for the next one.
+	start, err := pin.waitForValue(ctx, polarity, true)
+	if err != nil {
+		return 0, err
+	}
+	end, err := pin.waitForValue(ctx, polarity, false)
+	if err != nil {
+		return 0, err
+	}
+	return end.Sub(start), nil
+}
+
+// waitForValue waits for edges until the pin's value after an edge is equal
+// (or, if want is false, not equal) to the given value, and returns the
+// time at which that edge was reported.
+func (pin *gpioPin) waitForValue(ctx context.Context, value gpio.Value, want bool) (time.Time, error) {
+	for {
+		err := pin.WaitForEdgeContext(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		at := time.Now()
+
+		current, err := pin.Value()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if (current == value) == want {
+			return at, nil
+		}
+	}
+}
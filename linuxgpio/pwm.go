@@ -0,0 +1,124 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"math"
+	"time"
+)
+
+// PWMAnalysis describes a PWM signal observed by AnalyzePWM.
+type PWMAnalysis struct {
+	// FrequencyHz is the mean frequency of the signal.
+	FrequencyHz float64
+
+	// DutyCycle is the mean proportion of each period for which the
+	// signal is high, between 0 and 1.
+	DutyCycle float64
+
+	// Period is the mean time between consecutive rising edges.
+	Period time.Duration
+
+	// MinPulseWidth and MaxPulseWidth are the shortest and longest times
+	// for which the signal was observed to be high.
+	MinPulseWidth time.Duration
+	MaxPulseWidth time.Duration
+
+	// Jitter is the standard deviation of the observed periods.
+	Jitter time.Duration
+}
+
+// AnalyzePWM samples the given pin, which must be configured as an input,
+// as fast as possible for the given duration and then characterizes the
+// PWM signal it observed.
+//
+// Since each sample is taken with a system call, the resolution of the
+// measurements is limited to a few microseconds at best, and scheduling
+// delays can cause transitions to be seen late or missed entirely. This is
+// therefore suited only to signals of relatively low frequency, and the
+// jitter it reports includes the jitter of the sampling itself.
+//
+// Returns an error if fewer than two rising edges are observed, since at
+// least one whole period is required.
+func AnalyzePWM(pin Pin, duration time.Duration) (*PWMAnalysis, error) {
+	prev, err := pin.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	var rises []time.Time
+	var widths []time.Duration
+	deadline := time.Now().Add(duration)
+	for {
+		value, err := pin.Value()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		if now.After(deadline) {
+			break
+		}
+		if value == prev {
+			continue
+		}
+		prev = value
+
+		switch value {
+		case gpio.High:
+			rises = append(rises, now)
+		case gpio.Low:
+			if len(rises) > 0 {
+				widths = append(widths, now.Sub(rises[len(rises)-1]))
+			}
+		}
+	}
+
+	if len(rises) < 2 {
+		return nil, errors.New("did not observe a complete PWM period")
+	}
+
+	periods := make([]time.Duration, len(rises)-1)
+	for i := range periods {
+		periods[i] = rises[i+1].Sub(rises[i])
+	}
+	period := meanDuration(periods)
+
+	result := &PWMAnalysis{
+		FrequencyHz: 1 / period.Seconds(),
+		Period:      period,
+	}
+
+	if len(widths) > 0 {
+		result.DutyCycle = meanDuration(widths).Seconds() / period.Seconds()
+		result.MinPulseWidth = widths[0]
+		result.MaxPulseWidth = widths[0]
+		for _, width := range widths[1:] {
+			if width < result.MinPulseWidth {
+				result.MinPulseWidth = width
+			}
+			if width > result.MaxPulseWidth {
+				result.MaxPulseWidth = width
+			}
+		}
+	}
+
+	var variance float64
+	for _, p := range periods {
+		d := float64(p - period)
+		variance += d * d
+	}
+	variance /= float64(len(periods))
+	result.Jitter = time.Duration(math.Sqrt(variance))
+
+	return result, nil
+}
+
+func meanDuration(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
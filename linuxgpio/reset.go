@@ -0,0 +1,82 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+// PowerOnReset drives the reset input of an attached device, such as a
+// microcontroller or radio module, and can optionally wait for that device
+// to signal that it has finished booting.
+type PowerOnReset struct {
+	pin      Pin
+	active   gpio.Value
+	inactive gpio.Value
+}
+
+// NewPowerOnReset creates a reset driver for a device whose reset input is
+// connected to the given pin, which must already be configured as an output.
+// If activeLow is true the device is held in reset while the pin is low, as
+// is most common; otherwise it is held in reset while the pin is high.
+func NewPowerOnReset(resetPin Pin, activeLow bool) *PowerOnReset {
+	r := &PowerOnReset{
+		pin:      resetPin,
+		active:   gpio.High,
+		inactive: gpio.Low,
+	}
+	if activeLow {
+		r.active, r.inactive = gpio.Low, gpio.High
+	}
+	return r
+}
+
+// DoReset asserts the reset signal, holds it for the given duration, and then
+// releases it again.
+func (r *PowerOnReset) DoReset(pulseDuration time.Duration) error {
+	err := r.pin.SetValue(r.active)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(pulseDuration)
+
+	return r.pin.SetValue(r.inactive)
+}
+
+// WaitForBoot waits for the given indicator pin, which must be configured as
+// an input, to go high, as many devices do to signal that they are ready
+// after a reset. Returns an error if the indicator is still low once the
+// given timeout has passed.
+//
+// This changes the indicator's edge sensitivity to gpio.RisingEdge.
+func (r *PowerOnReset) WaitForBoot(indicator Pin, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := indicator.SetupEdgeWaiting(gpio.RisingEdge)
+	if err != nil {
+		return err
+	}
+
+	for {
+		value, err := indicator.Value()
+		if err != nil {
+			return err
+		}
+		if value == gpio.High {
+			return nil
+		}
+
+		_, err = indicator.WaitForEdgeSlice(1, ctx)
+		if err == context.DeadlineExceeded {
+			return fmt.Errorf("GPIO %d did not indicate boot within %s", indicator.Number(), timeout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"sync"
+	"time"
+)
+
+// RingOscillatorCounter measures the frequency of a signal on an input pin
+// by counting its rising edges over a fixed time, such as to check the
+// accuracy of an oscillator or to test a signal generator.
+//
+// Each edge must be handled by this process before the next one arrives,
+// since the kernel merges any edges that occur in the meantime into one.
+// The count is therefore accurate only for signals of a few kilohertz at
+// most, and is an underestimate for anything faster.
+type RingOscillatorCounter struct {
+	pin Pin
+
+	mu       sync.Mutex
+	count    uint64
+	duration time.Duration
+}
+
+// NewRingOscillatorCounter creates a counter for the given pin, which must
+// be configured as an input.
+func NewRingOscillatorCounter(pin Pin) *RingOscillatorCounter {
+	return &RingOscillatorCounter{pin: pin}
+}
+
+// CountFor counts rising edges on the pin for the given duration and
+// returns the count. This changes the pin's edge sensitivity to
+// gpio.RisingEdge.
+func (c *RingOscillatorCounter) CountFor(d time.Duration) (uint64, error) {
+	err := c.pin.SetupEdgeWaiting(gpio.RisingEdge)
+	if err != nil {
+		return 0, err
+	}
+	err = c.pin.FlushEdges()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	var count uint64
+	for {
+		err := c.pin.WaitForEdgeContext(ctx)
+		if err == context.DeadlineExceeded {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	c.mu.Lock()
+	c.count, c.duration = count, d
+	c.mu.Unlock()
+	return count, nil
+}
+
+// FrequencyHz returns the frequency in hertz implied by the most recent
+// successful call to CountFor, or zero if there hasn't been one.
+func (c *RingOscillatorCounter) FrequencyHz() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.duration <= 0 {
+		return 0
+	}
+	return float64(c.count) / c.duration.Seconds()
+}
@@ -0,0 +1,66 @@
+//go:build linux && rt
+// +build linux,rt
+
+package linuxgpio
+
+import (
+	"context"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// RealtimeEdgeWaiter is implemented by pins from this package when built
+// with the "rt" build tag.
+type RealtimeEdgeWaiter interface {
+	// WaitForEdgeRT is like WaitForEdgeContext, but runs the wait on an
+	// operating system thread temporarily switched to the SCHED_FIFO
+	// real-time scheduling policy, reducing scheduling jitter when the
+	// edge is detected. The thread's previous policy is restored before
+	// returning.
+	//
+	// Switching to a real-time policy requires the CAP_SYS_NICE capability
+	// or a suitable RLIMIT_RTPRIO limit; otherwise this fails with EPERM.
+	WaitForEdgeRT(ctx context.Context) error
+}
+
+func (pin *gpioPin) WaitForEdgeRT(ctx context.Context) error {
+	// The scheduling policy applies to a particular thread, so we must stay
+	// on the same thread for the whole wait.
+	runtime.LockOSThread()
+
+	tid := uintptr(syscall.Gettid())
+	oldPolicy, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETSCHEDULER, tid, 0, 0)
+	if errno != 0 {
+		runtime.UnlockOSThread()
+		return errno
+	}
+	var oldParam schedParam
+	_, _, errno = syscall.RawSyscall(syscall.SYS_SCHED_GETPARAM, tid, uintptr(unsafe.Pointer(&oldParam)), 0)
+	if errno != 0 {
+		runtime.UnlockOSThread()
+		return errno
+	}
+
+	err := setThreadScheduler(SchedFIFO)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+
+	err = pin.WaitForEdgeContext(ctx)
+
+	_, _, errno = syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, tid, oldPolicy, uintptr(unsafe.Pointer(&oldParam)))
+	if errno != 0 {
+		// We leave the thread locked so that it will be terminated when
+		// this goroutine exits, rather than letting other goroutines run
+		// on it with real-time priority.
+		if err == nil {
+			err = errno
+		}
+		return err
+	}
+
+	runtime.UnlockOSThread()
+	return err
+}
@@ -0,0 +1,110 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// SchedPolicy is a Linux scheduling policy, for use with
+// WithGoroutineScheduler.
+type SchedPolicy int
+
+const (
+	// SchedOther is the default time-sharing policy.
+	SchedOther SchedPolicy = 0
+
+	// SchedFIFO is a real-time policy under which a thread runs until it
+	// blocks or is preempted by a thread of higher priority.
+	SchedFIFO SchedPolicy = 1
+
+	// SchedRR is like SchedFIFO, except that threads of equal priority
+	// take turns.
+	SchedRR SchedPolicy = 2
+)
+
+// rtPriority is the priority used with the real-time scheduling policies.
+// It is just below the default priority of threaded interrupt handlers, so
+// that the handler for the GPIO interrupt we're waiting for can still
+// preempt us.
+const rtPriority = 49
+
+type schedParam struct {
+	priority int32
+}
+
+// threadSetup returns a function that applies the configuration requested
+// by WithGoroutineScheduler and WithGoroutineAffinity to the calling
+// thread, or nil if there is none.
+func (pin *gpioPin) threadSetup() func() error {
+	opts := pin.options
+	if !opts.goroutineSchedSet && !opts.goroutineAffinitySet {
+		return nil
+	}
+	return func() error {
+		if opts.goroutineAffinitySet {
+			err := setThreadAffinity(opts.goroutineAffinity)
+			if err != nil {
+				return err
+			}
+		}
+		if opts.goroutineSchedSet {
+			return setThreadScheduler(opts.goroutineSched)
+		}
+		return nil
+	}
+}
+
+// threadSetup returns the thread setup function for the given pin if it
+// was opened by this package, or nil otherwise. Wrappers use this so that
+// their own background goroutines honor the wrapped pin's options.
+func threadSetup(pin Pin) func() error {
+	if pin, ok := pin.(*gpioPin); ok {
+		return pin.threadSetup()
+	}
+	return nil
+}
+
+// maxAffinityCPU is one more than the highest CPU number that can be given
+// to setThreadAffinity, matching the size of glibc's cpu_set_t.
+const maxAffinityCPU = 1024
+
+// setThreadAffinity restricts the calling thread to running on the given
+// CPU. The caller must have locked its goroutine to the thread.
+func setThreadAffinity(cpu int) error {
+	if cpu < 0 || cpu >= maxAffinityCPU {
+		return fmt.Errorf("invalid CPU number %d", cpu)
+	}
+
+	var mask [maxAffinityCPU / 64]uint64
+	mask[cpu/64] = 1 << uint(cpu%64)
+
+	tid := uintptr(syscall.Gettid())
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, tid, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// setThreadScheduler switches the calling thread to the given scheduling
+// policy. The caller must have locked its goroutine to the thread.
+func setThreadScheduler(policy SchedPolicy) error {
+	param := schedParam{}
+	switch policy {
+	case SchedOther:
+	case SchedFIFO, SchedRR:
+		param.priority = rtPriority
+	default:
+		return fmt.Errorf("unsupported scheduling policy %d", policy)
+	}
+
+	tid := uintptr(syscall.Gettid())
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, tid, uintptr(policy), uintptr(unsafe.Pointer(&param)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
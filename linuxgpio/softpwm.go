@@ -0,0 +1,170 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"errors"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"sync"
+	"time"
+)
+
+// SoftPWM produces a PWM signal on an output pin by toggling it from a
+// background goroutine, for hardware whose PWM controller isn't available.
+//
+// Each edge is timed by the Go scheduler, so the signal has jitter of at
+// least tens of microseconds, and occasionally much more when the system is
+// busy. This is fine for dimming an LED, but frequencies above a few
+// kilohertz, or applications that need a precise duty cycle, such as some
+// hobby servos, will give poor results.
+type SoftPWM struct {
+	pin Pin
+
+	mu     sync.Mutex
+	freq   float64
+	duty   float64
+	stop   chan struct{}
+	done   chan struct{}
+	runErr error
+}
+
+// NewSoftPWM prepares to produce a PWM signal with the given frequency in
+// hertz and duty cycle between 0 and 1 on the given pin, which must already
+// be configured as an output. The signal doesn't begin until Start is
+// called.
+func NewSoftPWM(pin Pin, freq float64, duty float64) (*SoftPWM, error) {
+	if err := validateSoftPWMFrequency(freq); err != nil {
+		return nil, err
+	}
+	if err := validateSoftPWMDuty(duty); err != nil {
+		return nil, err
+	}
+	return &SoftPWM{pin: pin, freq: freq, duty: duty}, nil
+}
+
+// SetDuty changes the duty cycle, taking effect from the next period.
+func (p *SoftPWM) SetDuty(d float64) error {
+	if err := validateSoftPWMDuty(d); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.duty = d
+	p.mu.Unlock()
+	return nil
+}
+
+// SetFrequency changes the frequency, taking effect from the next period.
+func (p *SoftPWM) SetFrequency(f float64) error {
+	if err := validateSoftPWMFrequency(f); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.freq = f
+	p.mu.Unlock()
+	return nil
+}
+
+// Start begins producing the signal. Returns an error if it has already
+// been started and not yet stopped.
+func (p *SoftPWM) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop != nil {
+		return errors.New("software PWM is already running")
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	p.runErr = nil
+	go p.run(p.stop, p.done)
+	return nil
+}
+
+// Stop stops producing the signal and leaves the pin low. If the
+// background goroutine stopped early because it failed to set the pin's
+// value, returns that error instead.
+func (p *SoftPWM) Stop() error {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	if stop == nil {
+		return errors.New("software PWM is not running")
+	}
+
+	close(stop)
+	<-done
+
+	p.mu.Lock()
+	p.stop, p.done = nil, nil
+	err := p.runErr
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.pin.SetValue(gpio.Low)
+}
+
+func (p *SoftPWM) run(stop, done chan struct{}) {
+	defer close(done)
+
+	var ticker *time.Ticker
+	var current time.Duration
+	for {
+		p.mu.Lock()
+		period := time.Duration(float64(time.Second) / p.freq)
+		high := time.Duration(float64(period) * p.duty)
+		p.mu.Unlock()
+
+		if ticker == nil {
+			ticker = time.NewTicker(period)
+			defer ticker.Stop()
+		} else if period != current {
+			ticker.Reset(period)
+		}
+		current = period
+
+		err := p.cycle(period, high)
+		if err != nil {
+			p.mu.Lock()
+			p.runErr = err
+			p.mu.Unlock()
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// cycle produces the part of one period that precedes the next tick.
+func (p *SoftPWM) cycle(period, high time.Duration) error {
+	if high > 0 {
+		err := p.pin.SetValue(gpio.High)
+		if err != nil {
+			return err
+		}
+	}
+	if high < period {
+		time.Sleep(high)
+		return p.pin.SetValue(gpio.Low)
+	}
+	return nil
+}
+
+func validateSoftPWMFrequency(f float64) error {
+	if !(f > 0) || time.Duration(float64(time.Second)/f) <= 0 {
+		return fmt.Errorf("invalid PWM frequency %g", f)
+	}
+	return nil
+}
+
+func validateSoftPWMDuty(d float64) error {
+	if !(d >= 0 && d <= 1) {
+		return fmt.Errorf("invalid PWM duty cycle %g", d)
+	}
+	return nil
+}
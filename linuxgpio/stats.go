@@ -0,0 +1,134 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// ChipStats counts the operations this process has performed on the GPIOs
+// of a chip, as returned by GpioChip.Stats.
+type ChipStats struct {
+	// ExportCount, UnexportCount and OpenCount are the numbers of GPIOs
+	// successfully exported, unexported and opened, respectively.
+	ExportCount   int64
+	UnexportCount int64
+	OpenCount     int64
+
+	// ErrorCount is the number of exports, unexports and opens that failed.
+	ErrorCount int64
+}
+
+// gpioCounters holds the counts for a single GPIO number. Nodes don't know
+// which chip their GPIO belongs to, so we count by number and sum the
+// counts for a chip's range of numbers when asked for its statistics.
+type gpioCounters struct {
+	exports   int64
+	unexports int64
+	opens     int64
+	errors    int64
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = make(map[int]*gpioCounters)
+)
+
+// countersFor returns the counters for the given GPIO number, creating them
+// if necessary.
+func countersFor(number int) *gpioCounters {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	c, ok := counters[number]
+	if !ok {
+		c = &gpioCounters{}
+		counters[number] = c
+	}
+	return c
+}
+
+// countOp records the outcome of an operation on the given GPIO number. The
+// given field of its counters is incremented if err is nil, and its error
+// count otherwise.
+func countOp(number int, field func(*gpioCounters) *int64, err error) {
+	c := countersFor(number)
+	if err != nil {
+		atomic.AddInt64(&c.errors, 1)
+		return
+	}
+	atomic.AddInt64(field(c), 1)
+}
+
+func exportsField(c *gpioCounters) *int64   { return &c.exports }
+func unexportsField(c *gpioCounters) *int64 { return &c.unexports }
+func opensField(c *gpioCounters) *int64     { return &c.opens }
+
+// counters returns the counters for each GPIO number of the given chip
+// that has been used so far.
+func (chip *gpioChip) counters() []*gpioCounters {
+	first, err := chip.FirstGpioNumber()
+	if err != nil {
+		return nil
+	}
+	last, err := chip.LastGpioNumber()
+	if err != nil {
+		return nil
+	}
+
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	var result []*gpioCounters
+	for number, c := range counters {
+		if number >= first && number <= last {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (chip *gpioChip) Stats() ChipStats {
+	var stats ChipStats
+	for _, c := range chip.counters() {
+		stats.ExportCount += atomic.LoadInt64(&c.exports)
+		stats.UnexportCount += atomic.LoadInt64(&c.unexports)
+		stats.OpenCount += atomic.LoadInt64(&c.opens)
+		stats.ErrorCount += atomic.LoadInt64(&c.errors)
+	}
+	return stats
+}
+
+func (chip *gpioChip) ResetStats() {
+	for _, c := range chip.counters() {
+		atomic.StoreInt64(&c.exports, 0)
+		atomic.StoreInt64(&c.unexports, 0)
+		atomic.StoreInt64(&c.opens, 0)
+		atomic.StoreInt64(&c.errors, 0)
+	}
+}
+
+// PublishChipStats publishes the statistics of every GPIO chip as an expvar
+// variable with the given name, whose value is an object mapping each
+// chip's label to its ChipStats. Like expvar.Publish, this panics if the
+// name is already in use.
+func PublishChipStats(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		chips, err := ListGpioChips()
+		if err != nil {
+			return nil
+		}
+
+		result := make(map[string]ChipStats, len(chips))
+		for _, chip := range chips {
+			label, err := chip.Label()
+			if err != nil {
+				continue
+			}
+			result[label] = chip.Stats()
+		}
+		return result
+	}))
+}
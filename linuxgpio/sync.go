@@ -0,0 +1,55 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"github.com/apparentlymart/go-gpio/gpio"
+	"sync"
+)
+
+// GpioMutex is a mutual exclusion lock whose state is also visible on a
+// GPIO output: the pin is driven high while the lock is held and low while
+// it is not.
+//
+// Within this process it behaves like a sync.Mutex. Other devices connected
+// to the pin, such as a microcontroller or another board, can read it to
+// see when this process is inside the critical section it protects. It does
+// not prevent those other devices from doing anything; it's only an
+// indicator.
+type GpioMutex struct {
+	pin Pin
+	mu  sync.Mutex
+}
+
+// NewGpioMutex creates a new unlocked mutex indicated on the given pin,
+// which must already be configured as an output.
+//
+// The pin is not changed until the mutex is first locked or unlocked, so
+// the caller should drive it low initially if other devices might otherwise
+// see a stale lock.
+func NewGpioMutex(pin Pin) *GpioMutex {
+	return &GpioMutex{pin: pin}
+}
+
+// Lock locks the mutex, blocking until it is available, and then drives the
+// pin high. If the pin cannot be set, the mutex is released again and the
+// error is returned.
+func (m *GpioMutex) Lock() error {
+	m.mu.Lock()
+	err := m.pin.SetValue(gpio.High)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unlock drives the pin low and then unlocks the mutex. The mutex is
+// unlocked even if the pin cannot be set, in which case the error is
+// returned. As with sync.Mutex, it is a run-time error to unlock a mutex
+// that is not locked.
+func (m *GpioMutex) Unlock() error {
+	err := m.pin.SetValue(gpio.Low)
+	m.mu.Unlock()
+	return err
+}
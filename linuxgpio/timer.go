@@ -0,0 +1,39 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+)
+
+// GpioTimer measures time in units of edges on an input pin, such as the
+// revolutions of a shaft whose encoder produces a known number of edges per
+// revolution.
+type GpioTimer struct {
+	pin          Pin
+	edgesPerTick int
+}
+
+// NewGpioTimer creates a timer that counts one tick for every given number
+// of edges on the given pin, which must already have its edge sensitivity
+// configured.
+//
+// Edges that arrive before this process has handled the previous one are
+// merged by the kernel, so the timer runs slow if the input is too fast for
+// every edge to be handled.
+func NewGpioTimer(pin Pin, edgesPerTick int) *GpioTimer {
+	return &GpioTimer{pin: pin, edgesPerTick: edgesPerTick}
+}
+
+// Wait blocks until the given number of ticks have elapsed, counting from
+// when it was called, or returns ctx.Err() if the given context is
+// cancelled first.
+func (t *GpioTimer) Wait(ticks int, ctx context.Context) error {
+	for remaining := ticks * t.edgesPerTick; remaining > 0; remaining-- {
+		err := t.pin.WaitForEdgeContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
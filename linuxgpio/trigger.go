@@ -0,0 +1,86 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// Trigger runs a command each time an edge is detected on a pin. Create one
+// with TriggerOnEdge.
+type Trigger struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// TriggerOnEdge sets the given pin's edge sensitivity and then, in a
+// background goroutine, runs the given command each time an edge is
+// detected, until Stop is called.
+//
+// The command is run directly rather than via a shell, and inherits this
+// process's environment, standard output and standard error. The variables
+// GPIO_NUMBER and GPIO_VALUE are added to its environment, giving the GPIO
+// number and the value read just after the edge as either "0" or "1".
+//
+// Each command runs to completion before waiting for the next edge, so any
+// edges that occur while it is running are reported as at most one further
+// edge. The exit status of the command is ignored.
+func TriggerOnEdge(pin Pin, sensitivity gpio.EdgeSensitivity, command string, args ...string) (*Trigger, error) {
+	err := pin.SetupEdgeWaiting(sensitivity)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Trigger{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go t.run(ctx, pin, command, args)
+	return t, nil
+}
+
+// Stop stops waiting for edges, terminating the command if it is currently
+// running, and waits for the background goroutine to exit.
+//
+// Returns the error that caused the trigger to stop early if waiting for an
+// edge or reading the pin's value failed before Stop was called.
+func (t *Trigger) Stop() error {
+	t.cancel()
+	<-t.done
+	return t.err
+}
+
+func (t *Trigger) run(ctx context.Context, pin Pin, command string, args []string) {
+	defer close(t.done)
+
+	for {
+		events, err := pin.WaitForEdgeSlice(1, ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				t.err = err
+			}
+			return
+		}
+
+		value := "0"
+		if events[0].Value == gpio.High {
+			value = "1"
+		}
+
+		cmd := exec.CommandContext(ctx, command, args...)
+		cmd.Env = append(
+			os.Environ(),
+			"GPIO_NUMBER="+strconv.Itoa(pin.Number()),
+			"GPIO_VALUE="+value,
+		)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Run()
+	}
+}
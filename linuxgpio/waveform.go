@@ -0,0 +1,70 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"context"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"time"
+)
+
+// WaveformSample is one step of a waveform: a value to drive a pin to, and
+// how long to hold it there before moving on to the next sample.
+type WaveformSample struct {
+	Value    gpio.Value
+	Duration time.Duration
+}
+
+// WaveformGenerator plays waveforms on an output pin.
+//
+// Each sample is held by sleeping, so the timing is only as accurate as the
+// operating system's scheduler allows. This is fine for slow signals such as
+// indicator blink patterns, but is not suitable for anything requiring
+// precise timing.
+type WaveformGenerator struct {
+	pin Pin
+}
+
+// NewWaveformGenerator creates a generator that plays waveforms on the given
+// pin, which must already be configured as an output.
+func NewWaveformGenerator(pin Pin) *WaveformGenerator {
+	return &WaveformGenerator{pin: pin}
+}
+
+// Play plays the given waveform once, returning after the last sample's
+// duration has elapsed. The pin is left at the value of the last sample.
+func (gen *WaveformGenerator) Play(waveform []WaveformSample) error {
+	for _, sample := range waveform {
+		err := gen.pin.SetValue(sample.Value)
+		if err != nil {
+			return err
+		}
+		time.Sleep(sample.Duration)
+	}
+	return nil
+}
+
+// Loop plays the given waveform repeatedly until the given context is
+// cancelled, at which point it returns ctx.Err(). The pin is left at
+// whatever value it had when the context was cancelled.
+func (gen *WaveformGenerator) Loop(waveform []WaveformSample, ctx context.Context) error {
+	if len(waveform) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	for {
+		for _, sample := range waveform {
+			err := gen.pin.SetValue(sample.Value)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-time.After(sample.Duration):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
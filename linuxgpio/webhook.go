@@ -0,0 +1,174 @@
+// +build linux
+
+package linuxgpio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/apparentlymart/go-gpio/gpio"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions customizes the requests made by a Webhook.
+type WebhookOptions struct {
+	// RetryCount is the number of additional attempts to make if a request
+	// fails or receives a response with a non-2xx status code.
+	RetryCount int
+
+	// RetryDelay is the time to wait before the first retry, doubling for
+	// each further retry. Zero means 100 milliseconds.
+	RetryDelay time.Duration
+
+	// Timeout limits the time taken by each attempt. Zero means no limit.
+	Timeout time.Duration
+
+	// Headers are additional HTTP headers to include in each request.
+	Headers map[string]string
+
+	// OnError, if set, is called from the webhook's background goroutine
+	// with the error from the final attempt of each request that still
+	// fails after all retries. It should return quickly, since no further
+	// edges are waited for until it does.
+	OnError func(err error)
+}
+
+// defaultWebhookRetryDelay is used when WebhookOptions.RetryDelay is zero.
+const defaultWebhookRetryDelay = 100 * time.Millisecond
+
+// Webhook sends an HTTP request each time an edge is detected on a pin.
+// Create one with WebhookOnEdge.
+type Webhook struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+type webhookPayload struct {
+	Gpio      int    `json:"gpio"`
+	Value     string `json:"value"`
+	Timestamp string `json:"timestamp"`
+}
+
+// WebhookOnEdge starts a background goroutine that, each time an edge is
+// detected on the given pin, sends an HTTP POST request to the given URL
+// with a JSON body like the following, until Stop is called:
+//
+//	{"gpio": 17, "value": "high", "timestamp": "2006-01-02T15:04:05.999999999Z"}
+//
+// The pin's edge sensitivity must already have been configured.
+//
+// Each request, including any retries, completes before waiting for the next
+// edge, so any edges that occur in the meantime are reported as at most one
+// further request. A request that still fails after all retries is dropped,
+// after passing its error to opts.OnError if that is set.
+func WebhookOnEdge(pin Pin, url string, opts WebhookOptions) (*Webhook, error) {
+	// Catch an invalid URL now, rather than on the first edge.
+	_, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Webhook{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run(ctx, pin, url, opts)
+	return w, nil
+}
+
+// Stop stops waiting for edges, abandoning any request in progress, and waits
+// for the background goroutine to exit.
+//
+// Returns the error that caused the webhook to stop early if waiting for an
+// edge or reading the pin's value failed before Stop was called.
+func (w *Webhook) Stop() error {
+	w.cancel()
+	<-w.done
+	return w.err
+}
+
+func (w *Webhook) run(ctx context.Context, pin Pin, url string, opts WebhookOptions) {
+	defer close(w.done)
+
+	client := &http.Client{Timeout: opts.Timeout}
+	for {
+		events, err := pin.WaitForEdgeSlice(1, ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.err = err
+			}
+			return
+		}
+
+		payload := webhookPayload{
+			Gpio:      events[0].PinNumber,
+			Value:     "low",
+			Timestamp: events[0].Timestamp.Format(time.RFC3339Nano),
+		}
+		if events[0].Value == gpio.High {
+			payload.Value = "high"
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			// should never happen
+			panic(err)
+		}
+
+		err = postWebhookWithRetries(ctx, client, url, opts, body)
+		if err != nil && ctx.Err() == nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+}
+
+// postWebhookWithRetries sends the given body, retrying with exponential
+// backoff as described by opts, and returns the error from the final
+// attempt if none succeeded.
+func postWebhookWithRetries(ctx context.Context, client *http.Client, url string, opts WebhookOptions, body []byte) error {
+	delay := opts.RetryDelay
+	if delay == 0 {
+		delay = defaultWebhookRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := postWebhook(ctx, client, url, opts.Headers, body)
+		if err == nil || attempt >= opts.RetryCount {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+func postWebhook(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}